@@ -1,6 +1,9 @@
 package database
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Config represents the common database configuration
 type Config struct {
@@ -12,6 +15,7 @@ type Config struct {
 	MaxIdleConnections int           `yaml:"max_idle_connections"`
 	MaxLifeConnections time.Duration `yaml:"max_life_connections"`
 	DebugSQL           bool          `yaml:"debug_sql"`
+	WarnSQL            bool          `yaml:"warn_sql"`
 }
 
 // DefaultConfig returns a Config with default values
@@ -38,3 +42,27 @@ type Database interface {
 	// Ping checks if the database connection is alive
 	Ping() error
 }
+
+// Redact returns a copy of the config with the password in DSN masked,
+// it is called by xlog when the config is logged.
+func (c Config) Redact() interface{} {
+	c.DSN = redactDSN(c.DSN)
+	return c
+}
+
+// redactDSN 替换 user:password@ 中的密码，密码中可能包含 @，因此以最后一个 @ 为准
+func redactDSN(dsn string) string {
+	at := strings.LastIndex(dsn, "@")
+	if at < 0 {
+		return dsn
+	}
+	start := 0
+	if i := strings.Index(dsn[:at], "://"); i >= 0 {
+		start = i + 3
+	}
+	colon := strings.Index(dsn[start:at], ":")
+	if colon < 0 {
+		return dsn
+	}
+	return dsn[:start+colon+1] + "******" + dsn[at:]
+}
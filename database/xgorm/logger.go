@@ -0,0 +1,74 @@
+package xgorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rabbit-rm/xgo/xlog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// slowThreshold 超过该耗时的 SQL 以 Warn 级别输出
+const slowThreshold = 200 * time.Millisecond
+
+// gormLogger 将 gorm 的日志输出到名为 xgorm 的 xlog logger，
+// 可以通过 xlog.SetLoggerLevel("xgorm", ...) 单独调整级别
+type gormLogger struct {
+	log   xlog.Logger
+	level logger.LogLevel
+}
+
+func newGormLogger(level logger.LogLevel) logger.Interface {
+	return &gormLogger{log: xlog.Named("xgorm"), level: level}
+}
+
+func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &gormLogger{log: l.log, level: level}
+}
+
+func (l *gormLogger) Info(_ context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		l.log.Log(xlog.InfoLevel, fmt.Sprintf(msg, args...), xlog.Any("file", utils.FileWithLineNum()))
+	}
+}
+
+func (l *gormLogger) Warn(_ context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		l.log.Log(xlog.WarnLevel, fmt.Sprintf(msg, args...), xlog.Any("file", utils.FileWithLineNum()))
+	}
+}
+
+func (l *gormLogger) Error(_ context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		l.log.Log(xlog.ErrorLevel, fmt.Sprintf(msg, args...), xlog.Any("file", utils.FileWithLineNum()))
+	}
+}
+
+// Trace 输出 SQL：出错时为 Error，慢查询为 Warn，其余在 Info 模式下为 Info
+func (l *gormLogger) Trace(_ context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	fields := func(extra ...xlog.Field) []xlog.Field {
+		sql, rows := fc()
+		return append([]xlog.Field{
+			xlog.Any("sql", sql),
+			xlog.Any("rows", rows),
+			xlog.Any("elapsed", elapsed.String()),
+			xlog.Any("file", utils.FileWithLineNum()),
+		}, extra...)
+	}
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		l.log.Log(xlog.ErrorLevel, "sql failed", fields(xlog.Err(err))...)
+	case elapsed > slowThreshold && l.level >= logger.Warn:
+		l.log.Log(xlog.WarnLevel, "slow sql", fields()...)
+	case l.level >= logger.Info && l.log.Enabled(xlog.InfoLevel):
+		l.log.Log(xlog.InfoLevel, "sql", fields()...)
+	}
+}
@@ -3,6 +3,7 @@ package xgorm
 import (
 	"github.com/rabbit-rm/xgo/database"
 	"github.com/rabbit-rm/xgo/xerror"
+	"github.com/rabbit-rm/xgo/xlog"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -20,13 +21,16 @@ func NewGormDB(config *database.Config) *GormDB {
 }
 
 func (gdb *GormDB) Connect() error {
+	// 默认不输出 SQL，WarnSQL 输出出错与慢查询的 SQL，级别可以通过 xlog.SetLoggerLevel("xgorm", ...) 调整
 	logLevel := logger.Silent
 	if gdb.config.DebugSQL {
 		logLevel = logger.Info
+	} else if gdb.config.WarnSQL {
+		logLevel = logger.Warn
 	}
 
 	config := &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+		Logger: newGormLogger(logLevel),
 	}
 
 	db, err := gorm.Open(mysql.Open(gdb.config.DSN), config)
@@ -44,6 +48,7 @@ func (gdb *GormDB) Connect() error {
 	sqlDB.SetConnMaxLifetime(gdb.config.MaxLifeConnections)
 
 	gdb.db = db
+	xlog.Named("xgorm").Log(xlog.InfoLevel, "database connected", xlog.Any("config", gdb.config))
 	return nil
 }
 
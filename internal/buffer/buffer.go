@@ -121,3 +121,8 @@ func (b *Buffer) TrimNewline() {
 func (b *Buffer) Free() {
 	b.pool.put(b)
 }
+
+// AppendQuote appends a double-quoted Go string literal representing s.
+func (b *Buffer) AppendQuote(s string) {
+	b.bs = strconv.AppendQuote(b.bs, s)
+}
@@ -0,0 +1,141 @@
+// Package caller resolves the first stack frame outside of the logging packages.
+package caller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rabbit-rm/xgo/internal/pkg"
+	"github.com/rabbit-rm/xgo/internal/stacktrace"
+)
+
+var (
+	// helpers 通过 MarkHelper 标记的函数全名
+	helpers    sync.Map
+	hasHelpers atomic.Bool
+)
+
+// MarkHelper 将 skip 指定的函数标记为辅助函数，查找调用方时跳过，skip=0 标记 MarkHelper 的调用方
+func MarkHelper(skip int) {
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		if _, loaded := helpers.LoadOrStore(fn.Name(), struct{}{}); !loaded {
+			hasHelpers.Store(true)
+		}
+	}
+}
+
+// IsHelper 判断函数是否被标记为辅助函数
+func IsHelper(function string) bool {
+	if !hasHelpers.Load() {
+		return false
+	}
+	_, ok := helpers.Load(function)
+	return ok
+}
+
+// Frame 返回跳过 xlog、logrus、zap、slog、标准库 log 内部帧与辅助函数后的第一个调用方
+//
+// skip=0 从 Frame 的调用方开始查找
+func Frame(skip int) (runtime.Frame, bool) {
+	return FrameSkip(skip+1, 0)
+}
+
+// FrameSkip 与 Frame 相同，但在找到调用方后再向上跳过 callerSkip 个非辅助函数的帧
+func FrameSkip(skip, callerSkip int) (runtime.Frame, bool) {
+	stack := stacktrace.Capture(skip+1, stacktrace.Full)
+	defer stack.Free()
+	found := false
+	for {
+		frame, more := stack.Next()
+		if frame.Function != "" && !IsHelper(frame.Function) &&
+			(found || !isLogging(PackageName(frame.Function)) || strings.HasSuffix(frame.File, "_test.go")) {
+			if callerSkip == 0 {
+				return frame, true
+			}
+			found = true
+			callerSkip--
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+type frameKey struct{}
+
+// WithFrame 将已解析的调用方放入 context，后端优先使用它而不是重新查找
+func WithFrame(ctx context.Context, frame runtime.Frame) context.Context {
+	return context.WithValue(ctx, frameKey{}, frame)
+}
+
+// FromContext 返回 WithFrame 放入的调用方
+func FromContext(ctx context.Context) (runtime.Frame, bool) {
+	if ctx == nil {
+		return runtime.Frame{}, false
+	}
+	frame, ok := ctx.Value(frameKey{}).(runtime.Frame)
+	return frame, ok
+}
+
+// PackageName 返回函数全名所属的包路径
+func PackageName(function string) string {
+	for {
+		lastPeriod := strings.LastIndex(function, ".")
+		lastSlash := strings.LastIndex(function, "/")
+		if lastPeriod > lastSlash {
+			function = function[:lastPeriod]
+		} else {
+			break
+		}
+	}
+	return function
+}
+
+func isLogging(pkgName string) bool {
+	for _, name := range []string{pkg.XLogName(), pkg.LogrusName(), pkg.ZapName(), pkg.SlogName(), pkg.StdLogName()} {
+		if pkgName == name || strings.HasPrefix(pkgName, name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// workDir 缓存的工作目录，用于输出相对路径
+var workDir = sync.OnceValue(func() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(dir) + "/"
+})
+
+// Pretty 返回相对于工作目录的 file:line
+func Pretty(file string, line int) string {
+	return string(AppendPretty(nil, file, line))
+}
+
+// AppendPretty 将相对于工作目录的 file:line 追加到 b
+func AppendPretty(b []byte, file string, line int) []byte {
+	b = append(b, RelFile(file)...)
+	b = append(b, ':')
+	return strconv.AppendInt(b, int64(line), 10)
+}
+
+// RelFile 返回相对于工作目录的文件路径，不在工作目录下时返回原路径
+func RelFile(file string) string {
+	file = filepath.ToSlash(file)
+	if dir := workDir(); dir != "" {
+		file = strings.TrimPrefix(file, dir)
+	}
+	return file
+}
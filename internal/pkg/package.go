@@ -10,5 +10,14 @@ func LogrusName() string {
 }
 
 func ZapName() string {
-	return "github.com/sirupsen/logrus"
+	return "go.uber.org/zap"
+}
+
+func SlogName() string {
+	return "log/slog"
+}
+
+// StdLogName 标准库 log 包，通过 xlog.NewStdLog 输出时跳过其内部帧
+func StdLogName() string {
+	return "log"
 }
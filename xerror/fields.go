@@ -0,0 +1,35 @@
+package xerror
+
+import "fmt"
+
+// fieldsError 为错误附加结构化上下文，xlog 将其输出为 error.fields
+type fieldsError struct {
+	error
+	fields map[string]interface{}
+}
+
+// WithFields 为错误附加结构化上下文，错误链中多层附加同名字段时外层优先
+func WithFields(err error, fields map[string]interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &fieldsError{error: err, fields: fields}
+}
+
+// Fields 返回当前层附加的字段
+func (e *fieldsError) Fields() map[string]interface{} {
+	return e.fields
+}
+
+func (e *fieldsError) Unwrap() error {
+	return e.error
+}
+
+// Format 保持被包裹错误的格式化输出，例如 %+v 输出堆栈
+func (e *fieldsError) Format(s fmt.State, verb rune) {
+	if f, ok := e.error.(fmt.Formatter); ok {
+		f.Format(s, verb)
+		return
+	}
+	fmt.Fprintf(s, fmt.FormatString(s, verb), e.error)
+}
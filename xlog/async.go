@@ -0,0 +1,275 @@
+package xlog
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rabbit-rm/xgo/internal/buffer"
+)
+
+// LevelWriter 可感知日志级别的 Writer，Sink 的 Writer 实现该接口时按级别写入
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level Level, p []byte) (int, error)
+}
+
+// OverflowPolicy 异步缓冲区已满时的处理策略
+type OverflowPolicy int
+
+const (
+	// OverflowBlock 阻塞写入方直到缓冲区有空间
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest 丢弃新写入的日志
+	OverflowDropNewest
+	// OverflowDropDebug 优先丢弃缓冲区中最早的 Debug 日志，没有 Debug 日志时丢弃新写入的日志
+	OverflowDropDebug
+)
+
+// AsyncConfig 异步写入配置
+type AsyncConfig struct {
+	// BufferSize 缓冲的最大日志条数，默认 8192
+	BufferSize int `yaml:"buffer_size"`
+	// FlushSize 缓冲的字节数达到该值时立即写出，默认 32KiB
+	FlushSize int `yaml:"flush_size"`
+	// FlushInterval 定时写出的间隔，默认 1s
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	// Overflow 缓冲区已满时的处理策略
+	Overflow OverflowPolicy `yaml:"overflow"`
+}
+
+type asyncEntry struct {
+	level Level
+	data  []byte
+}
+
+var asyncBufferPool = buffer.NewPool()
+
+// AsyncWriter 将日志写入环形缓冲区，由后台 goroutine 按大小或时间批量写出
+//
+// Close 之后的写入在缓冲区写出完成后直接同步写到底层 Writer
+type AsyncWriter struct {
+	out    io.Writer
+	config AsyncConfig
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	ring    []asyncEntry
+	head    int
+	n       int
+	bytes   int
+	closed  bool
+	err     error
+
+	dropped   atomic.Uint64
+	wake      chan struct{}
+	flushes   chan chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewAsyncWriter creates an AsyncWriter writing to out and starts its flush goroutine.
+func NewAsyncWriter(out io.Writer, config AsyncConfig) *AsyncWriter {
+	if config.BufferSize <= 0 {
+		config.BufferSize = 8192
+	}
+	if config.FlushSize <= 0 {
+		config.FlushSize = 32 * 1024
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	w := &AsyncWriter{
+		out:     out,
+		config:  config,
+		ring:    make([]asyncEntry, config.BufferSize),
+		wake:    make(chan struct{}, 1),
+		flushes: make(chan chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	w.notFull = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// Write implements io.Writer, entries written without a level are treated as InfoLevel.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(InfoLevel, p)
+}
+
+// WriteLevel implements LevelWriter.
+func (w *AsyncWriter) WriteLevel(level Level, p []byte) (int, error) {
+	w.mu.Lock()
+	for !w.closed && w.n == len(w.ring) {
+		switch {
+		case w.config.Overflow == OverflowBlock:
+			w.signal()
+			w.notFull.Wait()
+			continue
+		case w.config.Overflow == OverflowDropDebug && level > DebugLevel && w.dropDebug():
+			continue
+		}
+		w.mu.Unlock()
+		w.dropped.Add(1)
+		return len(p), nil
+	}
+	if w.closed {
+		w.mu.Unlock()
+		// 等待最后一次写出完成，避免与缓冲区中的日志交错
+		<-w.stopped
+		if lw, ok := w.out.(LevelWriter); ok {
+			return lw.WriteLevel(level, p)
+		}
+		return w.out.Write(p)
+	}
+
+	w.ring[(w.head+w.n)%len(w.ring)] = asyncEntry{level: level, data: append([]byte(nil), p...)}
+	w.n++
+	w.bytes += len(p)
+	full := w.bytes >= w.config.FlushSize || w.n == len(w.ring)
+	w.mu.Unlock()
+
+	if full {
+		w.signal()
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of entries dropped because the buffer was full.
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Unwrap returns the underlying writer.
+func (w *AsyncWriter) Unwrap() io.Writer {
+	return w.out
+}
+
+// Sync writes out all buffered entries and syncs the underlying writer.
+func (w *AsyncWriter) Sync() error {
+	ack := make(chan struct{})
+	select {
+	case w.flushes <- ack:
+		<-ack
+	case <-w.stopped:
+	}
+
+	w.mu.Lock()
+	err := w.err
+	w.err = nil
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return syncWriter(w.out)
+}
+
+// Close flushes the buffered entries and stops the flush goroutine, the underlying writer is left open.
+func (w *AsyncWriter) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		w.mu.Lock()
+		w.closed = true
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+
+		close(w.done)
+		<-w.stopped
+
+		w.mu.Lock()
+		err = w.err
+		w.mu.Unlock()
+	})
+	return err
+}
+
+func (w *AsyncWriter) run() {
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+	defer close(w.stopped)
+
+	batch := make([]asyncEntry, 0, len(w.ring))
+	for {
+		select {
+		case <-w.wake:
+			batch = w.flush(batch)
+		case <-ticker.C:
+			batch = w.flush(batch)
+		case ack := <-w.flushes:
+			batch = w.flush(batch)
+			close(ack)
+		case <-w.done:
+			w.flush(batch)
+			return
+		}
+	}
+}
+
+// flush 取出缓冲区中的全部日志并一次性写出，底层 Writer 实现 LevelWriter 时逐条写出
+func (w *AsyncWriter) flush(batch []asyncEntry) []asyncEntry {
+	w.mu.Lock()
+	for i := 0; i < w.n; i++ {
+		idx := (w.head + i) % len(w.ring)
+		batch = append(batch, w.ring[idx])
+		w.ring[idx] = asyncEntry{}
+	}
+	w.head, w.n, w.bytes = 0, 0, 0
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	if len(batch) == 0 {
+		return batch
+	}
+	var err error
+	if lw, ok := w.out.(LevelWriter); ok {
+		// 按级别写入的 Writer 需要逐条写出以保留级别
+		for _, entry := range batch {
+			if _, e := lw.WriteLevel(entry.level, entry.data); e != nil {
+				err = e
+			}
+		}
+	} else {
+		buf := asyncBufferPool.Get()
+		for _, entry := range batch {
+			buf.AppendBytes(entry.data)
+		}
+		_, err = w.out.Write(buf.Bytes())
+		buf.Free()
+	}
+	if err != nil {
+		w.mu.Lock()
+		w.err = err
+		w.mu.Unlock()
+	}
+
+	clear(batch)
+	return batch[:0]
+}
+
+// dropDebug 移除缓冲区中最早的一条 Debug 日志，需持有锁
+func (w *AsyncWriter) dropDebug() bool {
+	for i := 0; i < w.n; i++ {
+		if w.ring[(w.head+i)%len(w.ring)].level != DebugLevel {
+			continue
+		}
+		w.bytes -= len(w.ring[(w.head+i)%len(w.ring)].data)
+		for j := i; j < w.n-1; j++ {
+			w.ring[(w.head+j)%len(w.ring)] = w.ring[(w.head+j+1)%len(w.ring)]
+		}
+		w.n--
+		w.ring[(w.head+w.n)%len(w.ring)] = asyncEntry{}
+		w.dropped.Add(1)
+		return true
+	}
+	return false
+}
+
+func (w *AsyncWriter) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,161 @@
+package xlog
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gateWriter 在 release 关闭前阻塞写入
+type gateWriter struct {
+	bytes.Buffer
+	release chan struct{}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.Buffer.Write(p)
+}
+
+func TestAsyncWriterDropDebug(t *testing.T) {
+	out := &gateWriter{release: make(chan struct{})}
+	w := NewAsyncWriter(out, AsyncConfig{BufferSize: 2, FlushInterval: time.Hour, Overflow: OverflowDropDebug})
+
+	// 缓冲区写满后由后台 goroutine 取走，并阻塞在 out.Write
+	w.WriteLevel(InfoLevel, []byte("1"))
+	w.WriteLevel(DebugLevel, []byte("d1"))
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		w.mu.Lock()
+		n := w.n
+		w.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("buffer was not flushed")
+		}
+	}
+
+	w.WriteLevel(DebugLevel, []byte("d2"))
+	w.WriteLevel(InfoLevel, []byte("2"))
+	w.WriteLevel(WarnLevel, []byte("3"))   // 丢弃 d2
+	w.WriteLevel(DebugLevel, []byte("d3")) // 没有可丢弃的 Debug 日志，丢弃自身
+	if dropped := w.Dropped(); dropped != 2 {
+		t.Errorf("expected 2 dropped entries, got %d", dropped)
+	}
+
+	close(out.release)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "1d123" {
+		t.Errorf("unexpected output %q", got)
+	}
+	// 关闭后直接写入
+	w.Write([]byte("4"))
+	if got := out.String(); got != "1d1234" {
+		t.Errorf("unexpected output after close %q", got)
+	}
+}
+
+// levelRecorder 记录每次写入的级别
+type levelRecorder struct {
+	bytes.Buffer
+	levels []Level
+}
+
+func (w *levelRecorder) WriteLevel(level Level, p []byte) (int, error) {
+	w.levels = append(w.levels, level)
+	return w.Write(p)
+}
+
+func TestAsyncSink(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			recorder := &levelRecorder{}
+			l, err := New(Config{
+				Backend: backend,
+				Level:   DebugLevel,
+				Sinks: []Sink{
+					{Writer: &out, Level: DebugLevel, Format: FormatJSON, Async: &AsyncConfig{FlushInterval: time.Hour}},
+					{Writer: recorder, Level: DebugLevel, Format: FormatJSON},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Debug("debug message")
+			l.With(Any("k", "v")).Warn("warn message")
+
+			if out.Len() != 0 {
+				t.Fatalf("expected buffered output, got %q", out.String())
+			}
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+			assertLines(t, "async", out.String(), "debug message", "warn message")
+			if !strings.Contains(out.String(), `"k":"v"`) {
+				t.Errorf("missing field in %q", out.String())
+			}
+			if want := []Level{DebugLevel, WarnLevel}; !reflect.DeepEqual(recorder.levels, want) {
+				t.Errorf("expected levels %v, got %v", want, recorder.levels)
+			}
+		})
+	}
+}
+
+// orderWriter 记录写入顺序，写入 block 时等待 release
+type orderWriter struct {
+	mu      sync.Mutex
+	out     bytes.Buffer
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (w *orderWriter) Write(p []byte) (int, error) {
+	if bytes.Contains(p, []byte("block")) {
+		close(w.entered)
+		<-w.release
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.out.Write(p)
+}
+
+func TestAsyncWriterWriteAfterClose(t *testing.T) {
+	out := &orderWriter{entered: make(chan struct{}), release: make(chan struct{})}
+	w := NewAsyncWriter(out, AsyncConfig{FlushInterval: time.Hour})
+	mustWriteAsync(t, w, "block\n")
+
+	closed := make(chan struct{})
+	go func() {
+		_ = w.Close()
+		close(closed)
+	}()
+	<-out.entered
+	// 最后一次写出进行中时的写入排在缓冲区的日志之后
+	written := make(chan struct{})
+	go func() {
+		mustWriteAsync(t, w, "late\n")
+		close(written)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(out.release)
+	<-closed
+	<-written
+
+	if got := out.out.String(); got != "block\nlate\n" {
+		t.Errorf("output = %q", got)
+	}
+}
+
+func mustWriteAsync(t *testing.T, w *AsyncWriter, s string) {
+	t.Helper()
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Error(err)
+	}
+}
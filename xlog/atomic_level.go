@@ -0,0 +1,256 @@
+package xlog
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rabbit-rm/xgo/internal/caller"
+)
+
+// AtomicLevel 可在运行时修改的日志级别，并支持按 logger 名称与包路径覆盖默认级别
+//
+// logger 名称覆盖按层级匹配，"xkafka" 同时作用于 "xkafka.consumer"；
+// 包路径覆盖根据调用方所在包匹配，"xmq/xkafka" 可匹配完整导入路径
+// "github.com/rabbit-rm/xgo/xmq/xkafka" 及其子包，多个覆盖同时匹配时取最长者
+type AtomicLevel struct {
+	level atomic.Int32
+	// 默认级别与所有覆盖中的最小、最大值，判断落在区间外时无需解析调用方
+	min atomic.Int32
+	max atomic.Int32
+
+	mu       sync.RWMutex
+	loggers  map[string]Level
+	packages map[string]Level
+	reverts  map[string]*revert
+}
+
+// revert 记录 TTL 到期后需要恢复的状态
+type revert struct {
+	timer   *time.Timer
+	restore func()
+}
+
+// NewAtomicLevel creates an AtomicLevel with the given default level.
+func NewAtomicLevel(level Level) *AtomicLevel {
+	a := &AtomicLevel{
+		loggers:  make(map[string]Level),
+		packages: make(map[string]Level),
+		reverts:  make(map[string]*revert),
+	}
+	a.SetLevel(level)
+	return a
+}
+
+// Level returns the default level.
+func (a *AtomicLevel) Level() Level {
+	return Level(a.level.Load())
+}
+
+// SetLevel changes the default level.
+func (a *AtomicLevel) SetLevel(level Level) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.level.Store(int32(level))
+	a.updateBounds()
+}
+
+// Enabled reports whether level is enabled by the default level, overrides are not considered.
+func (a *AtomicLevel) Enabled(level Level) bool {
+	return level >= a.Level()
+}
+
+// SetLoggerLevel overrides the level of the named logger and its children.
+func (a *AtomicLevel) SetLoggerLevel(name string, level Level) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.loggers[name] = level
+	a.updateBounds()
+}
+
+// UnsetLoggerLevel removes the override of the named logger.
+func (a *AtomicLevel) UnsetLoggerLevel(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.loggers, name)
+	a.updateBounds()
+}
+
+// SetPackageLevel overrides the level of entries logged from the package and its sub packages.
+func (a *AtomicLevel) SetPackageLevel(pkg string, level Level) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.packages[strings.Trim(pkg, "/")] = level
+	a.updateBounds()
+}
+
+// UnsetPackageLevel removes the override of the package.
+func (a *AtomicLevel) UnsetPackageLevel(pkg string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.packages, strings.Trim(pkg, "/"))
+	a.updateBounds()
+}
+
+// LoggerLevels returns a copy of the logger name overrides.
+func (a *AtomicLevel) LoggerLevels() map[string]Level {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return copyLevels(a.loggers)
+}
+
+// PackageLevels returns a copy of the package overrides.
+func (a *AtomicLevel) PackageLevels() map[string]Level {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return copyLevels(a.packages)
+}
+
+// enabled 判断名为 name 的 logger 在当前调用方处是否输出 level 级别的日志
+func (a *AtomicLevel) enabled(level Level, name string) bool {
+	if level < Level(a.min.Load()) {
+		return false
+	}
+	if level >= Level(a.max.Load()) {
+		return true
+	}
+	return level >= a.resolve(name)
+}
+
+func (a *AtomicLevel) resolve(name string) Level {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for n := name; n != ""; {
+		if level, ok := a.loggers[n]; ok {
+			return level
+		}
+		i := strings.LastIndex(n, ".")
+		if i < 0 {
+			break
+		}
+		n = n[:i]
+	}
+	if len(a.packages) > 0 {
+		if frame, ok := caller.Frame(0); ok {
+			if level, ok := a.packageLevel(caller.PackageName(frame.Function)); ok {
+				return level
+			}
+		}
+	}
+	return a.Level()
+}
+
+func (a *AtomicLevel) packageLevel(path string) (Level, bool) {
+	var (
+		level   Level
+		matched string
+	)
+	for pkg, l := range a.packages {
+		if len(pkg) > len(matched) && matchPackage(path, pkg) {
+			level, matched = l, pkg
+		}
+	}
+	return level, matched != ""
+}
+
+// matchPackage 判断导入路径 path 是否属于 pkg，pkg 可以是完整路径或路径的后缀片段
+func matchPackage(path, pkg string) bool {
+	return path == pkg ||
+		strings.HasPrefix(path, pkg+"/") ||
+		strings.HasSuffix(path, "/"+pkg) ||
+		strings.Contains(path, "/"+pkg+"/")
+}
+
+// updateBounds 需持有写锁
+func (a *AtomicLevel) updateBounds() {
+	lowest, highest := a.Level(), a.Level()
+	for _, levels := range []map[string]Level{a.loggers, a.packages} {
+		for _, level := range levels {
+			lowest = min(lowest, level)
+			highest = max(highest, level)
+		}
+	}
+	a.min.Store(int32(lowest))
+	a.max.Store(int32(highest))
+}
+
+// revertAfter 在 ttl 到期后执行 restore，同一 key 多次设置时保留最初的恢复状态；
+// ttl 为 0 表示永久生效，取消该 key 待执行的恢复
+func (a *AtomicLevel) revertAfter(key string, ttl time.Duration, restore func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	prev, ok := a.reverts[key]
+	if ok {
+		prev.timer.Stop()
+		delete(a.reverts, key)
+		restore = prev.restore
+	}
+	if ttl <= 0 {
+		return
+	}
+	r := &revert{restore: restore}
+	r.timer = time.AfterFunc(ttl, func() {
+		a.mu.Lock()
+		current := a.reverts[key]
+		if current == r {
+			delete(a.reverts, key)
+		}
+		a.mu.Unlock()
+		if current == r {
+			r.restore()
+		}
+	})
+	a.reverts[key] = r
+}
+
+func copyLevels(levels map[string]Level) map[string]Level {
+	cp := make(map[string]Level, len(levels))
+	for k, v := range levels {
+		cp[k] = v
+	}
+	return cp
+}
+
+// SetLevel changes the default level of the global logger.
+func SetLevel(level Level) {
+	if a := globalLevel(); a != nil {
+		a.SetLevel(level)
+	}
+}
+
+// SetLoggerLevel overrides the level of the named logger of the global logger.
+func SetLoggerLevel(name string, level Level) {
+	if a := globalLevel(); a != nil {
+		a.SetLoggerLevel(name, level)
+	}
+}
+
+// SetPackageLevel overrides the level of the package for the global logger.
+func SetPackageLevel(pkg string, level Level) {
+	if a := globalLevel(); a != nil {
+		a.SetPackageLevel(pkg, level)
+	}
+}
+
+// UnsetLoggerLevel removes the override of the named logger of the global logger.
+func UnsetLoggerLevel(name string) {
+	if a := globalLevel(); a != nil {
+		a.UnsetLoggerLevel(name)
+	}
+}
+
+// UnsetPackageLevel removes the override of the package for the global logger.
+func UnsetPackageLevel(pkg string) {
+	if a := globalLevel(); a != nil {
+		a.UnsetPackageLevel(pkg)
+	}
+}
+
+// globalLevel 返回全局 logger 的级别控制，自定义的 Logger 实现可能不支持
+func globalLevel() *AtomicLevel {
+	if l, ok := L().(interface{ AtomicLevel() *AtomicLevel }); ok {
+		return l.AtomicLevel()
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAtomicLevelLoggerOverride(t *testing.T) {
+	a := NewAtomicLevel(InfoLevel)
+	a.SetLoggerLevel("xkafka", DebugLevel)
+	a.SetLoggerLevel("xgorm", ErrorLevel)
+
+	cases := []struct {
+		name  string
+		level Level
+		want  bool
+	}{
+		{"", DebugLevel, false},
+		{"", InfoLevel, true},
+		{"xkafka", DebugLevel, true},
+		{"xkafka.consumer", DebugLevel, true},
+		{"xgorm", WarnLevel, false},
+		{"xgorm", ErrorLevel, true},
+	}
+	for _, c := range cases {
+		if got := a.enabled(c.level, c.name); got != c.want {
+			t.Errorf("enabled(%s, %q) = %v, want %v", c.level, c.name, got, c.want)
+		}
+	}
+
+	a.UnsetLoggerLevel("xkafka")
+	if a.enabled(DebugLevel, "xkafka") {
+		t.Error("override should be removed")
+	}
+}
+
+func TestMatchPackage(t *testing.T) {
+	path := "github.com/rabbit-rm/xgo/xmq/xkafka"
+	for _, pkg := range []string{path, "xmq/xkafka", "xkafka", "github.com/rabbit-rm/xgo/xmq", "xmq"} {
+		if !matchPackage(path, pkg) {
+			t.Errorf("%q should match %q", pkg, path)
+		}
+	}
+	for _, pkg := range []string{"kafka", "xmq/xkafka/consumer", "github.com/rabbit-rm/xgo/x"} {
+		if matchPackage(path, pkg) {
+			t.Errorf("%q should not match %q", pkg, path)
+		}
+	}
+
+	a := NewAtomicLevel(InfoLevel)
+	a.SetPackageLevel("xmq", WarnLevel)
+	a.SetPackageLevel("xmq/xkafka", DebugLevel)
+	if level, ok := a.packageLevel(path); !ok || level != DebugLevel {
+		t.Errorf("expected the longest override to win, got %s", level)
+	}
+}
+
+func TestPackageLevelOverride(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			l, err := New(Config{Backend: backend, Level: InfoLevel, Format: FormatJSON, Outputs: []io.Writer{&out}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a := l.(interface{ AtomicLevel() *AtomicLevel }).AtomicLevel()
+
+			// 测试代码位于 xlog 包，只有匹配该包的覆盖生效
+			a.SetPackageLevel("xmq/xkafka", DebugLevel)
+			l.Debug("other package")
+			a.SetPackageLevel("xlog", DebugLevel)
+			l.Debug("this package")
+			a.SetPackageLevel("xlog", ErrorLevel)
+			l.Warn("raised level")
+			a.UnsetPackageLevel("xlog")
+			l.Warn("override removed")
+
+			assertLines(t, "output", out.String(), "this package", "override removed")
+		})
+	}
+}
+
+func TestAtomicLevelServeHTTP(t *testing.T) {
+	a := NewAtomicLevel(InfoLevel)
+	srv := httptest.NewServer(a)
+	defer srv.Close()
+
+	do := func(method, url, body string) (int, levelResponse) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+url, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var state levelResponse
+		_ = json.NewDecoder(resp.Body).Decode(&state)
+		return resp.StatusCode, state
+	}
+
+	if code, state := do(http.MethodGet, "", ""); code != http.StatusOK || state.Level != InfoLevel {
+		t.Fatalf("unexpected GET response %d %+v", code, state)
+	}
+	if code, _ := do(http.MethodPut, "", `{"level":"verbose"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected bad request, got %d", code)
+	}
+	if code, _ := do(http.MethodPut, "", `{"level":"debug","ttl":"-5m"}`); code != http.StatusBadRequest || a.Level() != InfoLevel {
+		t.Fatalf("expected negative ttl to be rejected, got %d", code)
+	}
+	if code, _ := do(http.MethodPost, "", ""); code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected method not allowed, got %d", code)
+	}
+
+	code, state := do(http.MethodPut, "", `{"level":"debug","package":"xmq/xkafka"}`)
+	if code != http.StatusOK || state.Packages["xmq/xkafka"] != DebugLevel {
+		t.Fatalf("unexpected PUT response %d %+v", code, state)
+	}
+	if _, state = do(http.MethodDelete, "?package=xmq/xkafka", ""); len(state.Packages) != 0 {
+		t.Fatalf("expected override to be deleted, got %+v", state)
+	}
+
+	// 多次带 TTL 的修改在到期后恢复为最初的级别
+	do(http.MethodPut, "", `{"level":"error","ttl":"20ms"}`)
+	do(http.MethodPut, "", `{"level":"warn","ttl":"20ms"}`)
+	do(http.MethodPut, "", `{"level":"debug","logger":"xkafka","ttl":"20ms"}`)
+	// 包名首尾的 / 不影响还原为之前的级别
+	do(http.MethodPut, "", `{"level":"warn","package":"xmq/xkafka"}`)
+	do(http.MethodPut, "", `{"level":"debug","package":"/xmq/xkafka/","ttl":"20ms"}`)
+	if a.Level() != WarnLevel || a.LoggerLevels()["xkafka"] != DebugLevel || a.PackageLevels()["xmq/xkafka"] != DebugLevel {
+		t.Fatalf("unexpected levels before ttl")
+	}
+	deadline := time.Now().Add(time.Second)
+	for a.Level() != InfoLevel || len(a.LoggerLevels()) != 0 || a.PackageLevels()["xmq/xkafka"] != WarnLevel {
+		if time.Now().After(deadline) {
+			t.Fatalf("levels were not reverted: %s %v %v", a.Level(), a.LoggerLevels(), a.PackageLevels())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
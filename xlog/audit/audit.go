@@ -0,0 +1,183 @@
+// Package audit implements an append-only audit log, separate from the application logs.
+//
+// Every entry is a JSON line carrying a sequence number and the hash of the previous entry,
+// and is itself hashed with SHA-256, so Verify detects entries that were modified,
+// removed or reordered:
+//
+//	{"seq":2,"time":"2024-05-01T08:00:00.123456789Z","event":"user.login","fields":{"user":"alice"},"prev":"9f86…","hash":"60303…"}
+//
+// hash 为该行去掉 hash 字段后的 JSON 的 SHA-256，prev 为上一条的 hash，第一条的 prev 为空
+//
+// 哈希链不使用密钥，有写权限的人可以删除末尾的日志，或修改日志后重新计算之后所有的 hash；
+// 需要发现这类篡改时，定期将 Logger.State 保存到日志之外（如另一个系统或只追加的存储），
+// 校验时作为 anchor 传给 Verify 或 VerifyFiles
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rabbit-rm/xgo/xlog"
+	"github.com/rabbit-rm/xgo/xlog/xrotate"
+)
+
+// State 哈希链的位置，即最后一条日志的序号与 hash，零值表示链的起点
+type State struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// record 参与哈希计算的字段，顺序固定
+type record struct {
+	Seq    uint64                 `json:"seq"`
+	Time   string                 `json:"time"`
+	Event  string                 `json:"event"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+	Prev   string                 `json:"prev"`
+}
+
+type Option interface {
+	apply(*option)
+}
+
+type optionFunc func(*option)
+
+func (f optionFunc) apply(opt *option) {
+	f(opt)
+}
+
+type option struct {
+	State State
+	Clock func() time.Time
+}
+
+// WithState 从 state 继续哈希链，用于进程重启后继续写入
+func WithState(state State) Option {
+	return optionFunc(func(opt *option) {
+		opt.State = state
+	})
+}
+
+// WithClock 设置日志时间的来源，默认 time.Now
+func WithClock(clock func() time.Time) Option {
+	return optionFunc(func(opt *option) {
+		opt.Clock = clock
+	})
+}
+
+// Logger 审计日志，并发安全；写入失败时返回错误且不推进哈希链
+type Logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	state State
+	clock func() time.Time
+}
+
+// New creates a Logger writing to w.
+func New(w io.Writer, opts ...Option) *Logger {
+	opt := &option{Clock: time.Now}
+	for _, o := range opts {
+		o.apply(opt)
+	}
+	return &Logger{w: w, state: opt.State, clock: opt.Clock}
+}
+
+// NewFile 创建写入 xrotate.Writer 的 Logger，pattern 与 xrotate.NewWriter 相同；
+// 已有文件时从最新文件的最后一条继续哈希链，轮转后新文件的第一条指向上一个文件的最后一条；
+// 末尾的日志或最新的文件被删除时会从更早的位置继续，应先用链外保存的 anchor 调用 VerifyFiles
+//
+// 审计日志默认不清理旧文件，opts 中的 WithMaxAge、WithRotationCount 等由调用方显式开启；
+// 压缩后的文件不能由 VerifyFiles 校验
+func NewFile(pattern string, opts ...xrotate.Option) (*Logger, error) {
+	state, err := LastState(pattern)
+	if err != nil {
+		return nil, err
+	}
+	w, err := xrotate.NewWriter(pattern, append([]xrotate.Option{xrotate.WithMaxAge(0)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return New(w, WithState(state)), nil
+}
+
+// Log 写入一条审计日志，字段值中的 error 按 Error() 输出
+func (l *Logger) Log(event string, fields ...xlog.Field) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r := record{
+		Seq:   l.state.Seq + 1,
+		Time:  l.clock().UTC().Format(time.RFC3339Nano),
+		Event: event,
+		Prev:  l.state.Hash,
+	}
+	if len(fields) > 0 {
+		r.Fields = make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			if err, ok := f.Value.(error); ok {
+				r.Fields[f.Key] = err.Error()
+				continue
+			}
+			r.Fields[f.Key] = f.Value
+		}
+	}
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("audit: encode entry: %w", err)
+	}
+	hash := hashOf(body)
+	line := make([]byte, 0, len(body)+len(hash)+12)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, `,"hash":"`...)
+	line = append(line, hash...)
+	line = append(line, "\"}\n"...)
+	if _, err := l.w.Write(line); err != nil {
+		return fmt.Errorf("audit: write entry: %w", err)
+	}
+	l.state = State{Seq: r.Seq, Hash: hash}
+	return nil
+}
+
+// State returns the position of the last entry written.
+func (l *Logger) State() State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.state
+}
+
+// Close closes the underlying writer if it implements io.Closer.
+func (l *Logger) Close() error {
+	if c, ok := l.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func hashOf(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashSuffixLen `,"hash":"<64 位十六进制>"}` 的长度
+const hashSuffixLen = len(`,"hash":""}`) + sha256.Size*2
+
+// parse 解析一行审计日志，返回参与哈希计算的部分与其中的字段
+func parse(line []byte) (body []byte, r record, hash string, err error) {
+	n := len(line)
+	if n < hashSuffixLen+2 || !bytes.HasPrefix(line[n-hashSuffixLen:], []byte(`,"hash":"`)) || !bytes.HasSuffix(line, []byte(`"}`)) {
+		return nil, r, "", errors.New("missing hash")
+	}
+	hash = string(line[n-hashSuffixLen+len(`,"hash":"`) : n-2])
+	body = append(bytes.Clone(line[:n-hashSuffixLen]), '}')
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, r, "", err
+	}
+	return body, r, hash, nil
+}
@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rabbit-rm/xgo/xlog"
+	"github.com/rabbit-rm/xgo/xlog/xrotate"
+)
+
+func write(t *testing.T, l *Logger, events ...string) {
+	t.Helper()
+	for _, event := range events {
+		if err := l.Log(event, xlog.Any("user", "alice"), xlog.Any("err", errors.New("denied"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	var first, second bytes.Buffer
+	l := New(&first)
+	write(t, l, "login", "read", "logout")
+	// 轮转到新的输出，链在新文件中延续
+	l2 := New(&second, WithState(l.State()))
+	write(t, l2, "login")
+
+	state, err := Verify(bytes.NewReader(first.Bytes()), State{}, State{})
+	if err != nil || state.Seq != 3 {
+		t.Fatalf("verify first: %v, %+v", err, state)
+	}
+	if state, err = Verify(bytes.NewReader(second.Bytes()), state, l2.State()); err != nil || state != l2.State() {
+		t.Fatalf("verify second: %v, %+v", err, state)
+	}
+
+	lines := strings.SplitAfter(first.String(), "\n")
+	for name, tampered := range map[string]string{
+		"modified":  lines[0] + strings.Replace(lines[1], "alice", "bob", 1) + lines[2],
+		"removed":   lines[0] + lines[2],
+		"reordered": lines[0] + lines[2] + lines[1],
+		"truncated": lines[1] + lines[2],
+	} {
+		_, err := Verify(strings.NewReader(tampered), State{}, State{})
+		var verr *VerifyError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: expected a VerifyError, got %v", name, err)
+		}
+	}
+	// 删除末尾的日志只能通过链外保存的 anchor 发现
+	anchor := l.State()
+	if _, err := Verify(strings.NewReader(lines[0]+lines[1]), State{}, State{}); err != nil {
+		t.Fatalf("truncated tail without anchor: %v", err)
+	}
+	var verr *VerifyError
+	if _, err := Verify(strings.NewReader(lines[0]+lines[1]), State{}, anchor); !errors.As(err, &verr) {
+		t.Errorf("truncated tail: expected a VerifyError, got %v", err)
+	}
+	// 重新计算 hash 伪造的链与 anchor 不一致
+	var forgedLog bytes.Buffer
+	write(t, New(&forgedLog), "login", "edit", "logout")
+	if _, err := Verify(&forgedLog, State{}, anchor); !errors.As(err, &verr) {
+		t.Errorf("recomputed chain: expected a VerifyError, got %v", err)
+	}
+	// 修改 prev 同样会使 hash 不匹配
+	forged := strings.Replace(lines[1], `"prev":"`, `"prev":"0`, 1)
+	if _, err := Verify(strings.NewReader(lines[0]+forged), State{}, State{}); err == nil {
+		t.Error("expected forged entry to fail verification")
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	pattern := filepath.Join(t.TempDir(), "audit", "audit.%Y%m%d.log")
+	l, err := NewFile(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, l, "login", "read")
+	_ = l.Close()
+
+	// 重新打开后从最后一条继续
+	l, err = NewFile(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.State().Seq; got != 2 {
+		t.Fatalf("resumed at seq %d, want 2", got)
+	}
+	write(t, l, "logout")
+	_ = l.Close()
+
+	files, err := Files(pattern)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("files = %v, %v", files, err)
+	}
+	state, err := VerifyFiles(State{}, State{}, files...)
+	if err != nil || state.Seq != 3 {
+		t.Fatalf("verify: %v, %+v", err, state)
+	}
+
+	data, _ := os.ReadFile(files[0])
+	_ = os.WriteFile(files[0], bytes.Replace(data, []byte(`"event":"read"`), []byte(`"event":"edit"`), 1), 0o644)
+	_, err = VerifyFiles(State{}, State{}, files...)
+	var verr *VerifyError
+	if !errors.As(err, &verr) || verr.Line != 2 || verr.File != files[0] {
+		t.Fatalf("expected modification on line 2, got %v", err)
+	}
+}
+
+func TestNewFileKeepsOldFiles(t *testing.T) {
+	pattern := filepath.Join(t.TempDir(), "audit.%Y%m%d.log")
+	now := time.Now()
+	var day atomic.Int64
+	clock := func() time.Time { return now.Add(time.Duration(day.Load()) * 24 * time.Hour) }
+	l, err := NewFile(pattern, xrotate.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, l, "login")
+	files, _ := Files(pattern)
+	past := now.Add(-365 * 24 * time.Hour)
+	if err := os.Chtimes(files[0], past, past); err != nil {
+		t.Fatal(err)
+	}
+	// 轮转到新文件，旧文件默认不清理
+	day.Store(1)
+	write(t, l, "logout")
+	_ = l.Close()
+
+	files, err = Files(pattern)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("files = %v, %v", files, err)
+	}
+	if state, err := VerifyFiles(State{}, State{}, files...); err != nil || state.Seq != 2 {
+		t.Fatalf("verify: %v, %+v", err, state)
+	}
+}
+
+func TestVerifyFilesAnchor(t *testing.T) {
+	pattern := filepath.Join(t.TempDir(), "audit.%Y%m%d.log")
+	now := time.Now()
+	var day atomic.Int64
+	clock := func() time.Time { return now.Add(time.Duration(day.Load()) * 24 * time.Hour) }
+	l, err := NewFile(pattern, xrotate.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, l, "login")
+	day.Store(1)
+	write(t, l, "logout")
+	anchor := l.State()
+	_ = l.Close()
+
+	// 删除最新的文件后 NewFile 从更早的位置继续，只有 anchor 能发现
+	files, _ := Files(pattern)
+	if err := os.Remove(files[1]); err != nil {
+		t.Fatal(err)
+	}
+	if state, err := LastState(pattern); err != nil || state.Seq != 1 {
+		t.Fatalf("last state = %+v, %v", state, err)
+	}
+	files, _ = Files(pattern)
+	if _, err := VerifyFiles(State{}, State{}, files...); err != nil {
+		t.Fatalf("verify without anchor: %v", err)
+	}
+	var verr *VerifyError
+	if _, err := VerifyFiles(State{}, anchor, files...); !errors.As(err, &verr) || verr.Seq != 1 {
+		t.Fatalf("expected removed entries after seq 1, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rabbit-rm/xgo/xlog/xrotate"
+)
+
+// VerifyError 校验失败的位置与原因
+type VerifyError struct {
+	File string
+	// Line 从 1 开始的行号
+	Line   int
+	Seq    uint64
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("audit: %s:%d: seq %d: %s", e.File, e.Line, e.Seq, e.Reason)
+	}
+	return fmt.Sprintf("audit: line %d: seq %d: %s", e.Line, e.Seq, e.Reason)
+}
+
+// Verify 从 from 开始校验 r 中的审计日志，返回最后一条的位置；
+// from 为零值时要求从链的第一条开始，被修改、删除或调换顺序的日志返回 *VerifyError
+//
+// 只凭日志本身无法发现末尾日志被删除，anchor 为保存在日志之外的 Logger.State，
+// 不为零值时要求链经过 anchor，即序号为 anchor.Seq 的日志存在且 hash 一致
+func Verify(r io.Reader, from, anchor State) (State, error) {
+	state, err := verify(r, "", from, anchor)
+	if err != nil {
+		return state, err
+	}
+	return state, checkAnchor(state, anchor, "")
+}
+
+// VerifyFiles 按顺序校验多个文件，哈希链跨文件延续，anchor 与 Verify 相同
+func VerifyFiles(from, anchor State, files ...string) (State, error) {
+	state := from
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return state, err
+		}
+		state, err = verify(f, name, state, anchor)
+		_ = f.Close()
+		if err != nil {
+			return state, err
+		}
+	}
+	file := ""
+	if len(files) > 0 {
+		file = files[len(files)-1]
+	}
+	return state, checkAnchor(state, anchor, file)
+}
+
+// checkAnchor 链在 anchor 之前结束时说明末尾的日志或文件被删除
+func checkAnchor(state, anchor State, file string) error {
+	if anchor.Seq <= state.Seq {
+		return nil
+	}
+	return &VerifyError{File: file, Seq: state.Seq,
+		Reason: fmt.Sprintf("entries after seq %d were removed, anchor is at seq %d", state.Seq, anchor.Seq)}
+}
+
+func verify(r io.Reader, file string, state, anchor State) (State, error) {
+	reader := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			if errors.Is(err, io.EOF) {
+				return state, nil
+			}
+			return state, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			continue
+		}
+		body, rec, hash, perr := parse(line)
+		fail := func(reason string) (State, error) {
+			return state, &VerifyError{File: file, Line: lineNo, Seq: rec.Seq, Reason: reason}
+		}
+		switch {
+		case perr != nil:
+			return fail("malformed entry: " + perr.Error())
+		case hashOf(body) != hash:
+			return fail("entry was modified")
+		case rec.Seq <= state.Seq:
+			return fail(fmt.Sprintf("entry is out of order, previous seq is %d", state.Seq))
+		case rec.Seq > state.Seq+1:
+			return fail(fmt.Sprintf("entries %d to %d were removed", state.Seq+1, rec.Seq-1))
+		case rec.Prev != state.Hash:
+			return fail("previous hash does not match, the chain was altered")
+		case rec.Seq == anchor.Seq && hash != anchor.Hash:
+			return fail("hash does not match the anchor, the chain was recomputed")
+		}
+		state = State{Seq: rec.Seq, Hash: hash}
+	}
+}
+
+// Files 返回 pattern 生成的文件，按时间先后排序，pattern 与 xrotate.NewWriter 相同
+func Files(pattern string) ([]string, error) {
+	return xrotate.Files(pattern)
+}
+
+// LastState 返回与 pattern 匹配的最新文件中最后一条日志的位置，没有日志时返回零值；
+// 末尾的日志或最新的文件被删除时返回更早的位置，需要与链外保存的 anchor 对比才能发现
+func LastState(pattern string) (State, error) {
+	files, err := Files(pattern)
+	if err != nil {
+		return State{}, err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		data, err := os.ReadFile(files[i])
+		if err != nil {
+			return State{}, err
+		}
+		data = bytes.TrimRight(data, "\r\n")
+		if len(data) == 0 {
+			continue
+		}
+		line := data[bytes.LastIndexByte(data, '\n')+1:]
+		_, rec, hash, err := parse(line)
+		if err != nil {
+			return State{}, &VerifyError{File: files[i], Line: bytes.Count(data, []byte{'\n'}) + 1, Reason: "malformed last entry: " + err.Error()}
+		}
+		return State{Seq: rec.Seq, Hash: hash}, nil
+	}
+	return State{}, nil
+}
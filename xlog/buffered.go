@@ -0,0 +1,151 @@
+package xlog
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+)
+
+type BufferedOption interface {
+	apply(*bufferedOption)
+}
+
+type bufferedOptionFunc func(*bufferedOption)
+
+func (f bufferedOptionFunc) apply(opt *bufferedOption) {
+	f(opt)
+}
+
+type bufferedOption struct {
+	Threshold Level
+	Trigger   Level
+	MaxSize   int
+}
+
+// WithBufferThreshold 低于 level 的日志先缓存，默认 InfoLevel，即只缓存 Debug 日志
+func WithBufferThreshold(level Level) BufferedOption {
+	return bufferedOptionFunc(func(opt *bufferedOption) {
+		opt.Threshold = level
+	})
+}
+
+// WithBufferTrigger 达到 level 的日志触发写出缓存，默认 ErrorLevel
+func WithBufferTrigger(level Level) BufferedOption {
+	return bufferedOptionFunc(func(opt *bufferedOption) {
+		opt.Trigger = level
+	})
+}
+
+// WithBufferSize 缓存的最大条数，超过时丢弃最早的日志，默认 1000
+func WithBufferSize(size int) BufferedOption {
+	return bufferedOptionFunc(func(opt *bufferedOption) {
+		opt.MaxSize = size
+	})
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l, see FromContext.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or the global logger if there is none.
+func FromContext(ctx context.Context) Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(Logger); ok {
+			return l
+		}
+	}
+	return L()
+}
+
+// Buffered 为一个请求或一条 Kafka 消息创建带缓存的 logger，并放入返回的 context：
+// 低于阈值且级别未开启的日志先缓存在内存中，直到出现达到触发级别的日志时按顺序写出，
+// 之后该作用域内低于阈值的日志直接写出；没有触发时缓存随作用域结束一起丢弃；
+// 级别已开启的日志总是直接写出
+//
+// logger 取自 FromContext(ctx)，其 With 与 Named 产生的子 logger 共享同一个缓存
+func Buffered(ctx context.Context, opts ...BufferedOption) (context.Context, Logger) {
+	opt := &bufferedOption{Threshold: InfoLevel, Trigger: ErrorLevel, MaxSize: 1000}
+	for _, o := range opts {
+		o.apply(opt)
+	}
+	if opt.MaxSize <= 0 {
+		opt.MaxSize = 1000
+	}
+	parent := FromContext(ctx)
+	l, ok := parent.(*logger)
+	if !ok {
+		// 其他 Logger 实现不支持缓存，原样使用
+		return NewContext(ctx, parent), parent
+	}
+	child := *l
+	child.scope = &bufferScope{threshold: opt.Threshold, trigger: opt.Trigger, maxSize: opt.MaxSize}
+	return NewContext(ctx, &child), &child
+}
+
+// bufferScope Buffered 作用域内共享的缓存
+type bufferScope struct {
+	threshold Level
+	trigger   Level
+	maxSize   int
+
+	mu        sync.Mutex
+	entries   []bufferedEntry
+	triggered bool
+}
+
+// bufferedEntry 一条已格式化、等待写出的日志
+type bufferedEntry struct {
+	l        *logger
+	time     time.Time
+	level    Level
+	template string
+	msg      string
+	fields   []Field
+	frame    *runtime.Frame
+}
+
+func (e bufferedEntry) write() {
+	e.l.count(e.level)
+	e.l.core.Log(e.time, e.level, e.template, e.msg, e.fields, e.frame)
+}
+
+// buffers 判断 level 是否低于缓存阈值，scope 为 nil 时返回 false
+func (s *bufferScope) buffers(level Level) bool {
+	return s != nil && level < s.threshold
+}
+
+// hold 缓存低于阈值且级别未开启的日志并返回 true；达到触发级别时先写出缓存的日志，返回 false
+func (s *bufferScope) hold(e bufferedEntry, enabled bool) bool {
+	s.mu.Lock()
+	switch {
+	case s.triggered:
+		s.mu.Unlock()
+		return false
+	case e.level < s.threshold && !enabled:
+		if len(s.entries) == s.maxSize {
+			s.entries[0] = bufferedEntry{}
+			s.entries = s.entries[1:]
+		}
+		// 调用方可能复用字段切片
+		e.fields = slices.Clone(e.fields)
+		s.entries = append(s.entries, e)
+		s.mu.Unlock()
+		return true
+	case e.level >= s.trigger:
+		entries := s.entries
+		s.entries, s.triggered = nil, true
+		s.mu.Unlock()
+		for _, buffered := range entries {
+			buffered.write()
+		}
+		return false
+	default:
+		s.mu.Unlock()
+		return false
+	}
+}
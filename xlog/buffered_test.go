@@ -0,0 +1,69 @@
+package xlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBuffered(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			l, err := New(Config{Backend: backend, Level: InfoLevel, Format: FormatJSON, Outputs: []io.Writer{&out}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := NewContext(context.Background(), l)
+
+			// 没有错误时 Debug 日志被丢弃
+			_, ok := Buffered(ctx)
+			ok.Debug("ok debug")
+			ok.Info("ok info")
+			if strings.Contains(out.String(), "ok debug") || !strings.Contains(out.String(), "ok info") {
+				t.Fatalf("unexpected output:\n%s", out.String())
+			}
+
+			out.Reset()
+			ctx, failed := Buffered(ctx, WithBufferSize(2))
+			failed.Debug("dropped")
+			failed.Named("db").Debug("query")
+			FromContext(ctx).Log(DebugLevel, "retry", Any("attempt", 2))
+			if out.Len() != 0 {
+				t.Fatalf("debug entries must be buffered, got:\n%s", out.String())
+			}
+			failed.Error("failed")
+			failed.Debug("after")
+
+			var msgs []string
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				var entry map[string]interface{}
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Fatalf("invalid json %q: %v", line, err)
+				}
+				msgs = append(msgs, entry["msg"].(string))
+				if entry["msg"] == "query" && entry["logger"] != "db" {
+					t.Errorf("buffered entry lost its logger name: %s", line)
+				}
+			}
+			if strings.Join(msgs, ",") != "query,retry,failed,after" {
+				t.Fatalf("got %v, want the last 2 buffered entries before the error", msgs)
+			}
+
+			// 级别已开启的日志不缓存，没有错误时同样输出
+			var debug bytes.Buffer
+			dl, err := New(Config{Backend: backend, Level: DebugLevel, Format: FormatJSON, Outputs: []io.Writer{&debug}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, enabled := Buffered(NewContext(context.Background(), dl))
+			enabled.Debug("enabled debug")
+			if !strings.Contains(debug.String(), "enabled debug") {
+				t.Fatalf("enabled debug entry was buffered, got:\n%s", debug.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package xlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/rabbit-rm/xgo/xerror"
+)
+
+func logHelper(l Logger, msg string) {
+	Helper()
+	l.Info(msg)
+}
+
+func logSkip(l Logger, msg string) {
+	l.WithCallerSkip(1).Info(msg)
+}
+
+func newNotFound(id int) error {
+	Helper()
+	return xerror.NewWithCaller("user %d not found", id)
+}
+
+func TestCaller(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			l, err := New(Config{Backend: backend, Format: FormatJSON, Caller: true, Outputs: []io.Writer{&out}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			check := func(name string, line int) {
+				t.Helper()
+				want := fmt.Sprintf("caller_test.go:%d", line)
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("%s: expected caller %s in %s", name, want, out.String())
+				}
+				out.Reset()
+			}
+
+			_, _, line, _ := runtime.Caller(0)
+			l.Info("direct")
+			check("direct", line+1)
+
+			_, _, line, _ = runtime.Caller(0)
+			l.With(Any("k", "v")).Log(InfoLevel, "with")
+			check("with", line+1)
+
+			_, _, line, _ = runtime.Caller(0)
+			logHelper(l, "helper")
+			check("helper", line+1)
+
+			_, _, line, _ = runtime.Caller(0)
+			logSkip(l, "skip")
+			check("skip", line+1)
+
+			_, _, line, _ = runtime.Caller(0)
+			err = newNotFound(1)
+			if want := fmt.Sprintf("caller_test.go:%d", line+1); !strings.Contains(err.Error(), want) {
+				t.Errorf("xerror: expected caller %s in %q", want, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package xlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Backend 日志后端实现
+type Backend string
+
+const (
+	BackendLogrus Backend = "logrus"
+	BackendZap    Backend = "zap"
+	BackendSlog   Backend = "slog"
+	// BackendNative 直接编码到池化 buffer 的内置后端，编码基本类型字段不分配；
+	// 每条日志仍有可变参数切片、消息文本与调用方等少量分配，见 bench_test.go
+	BackendNative Backend = "native"
+)
+
+// Format 日志输出格式
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+	// FormatConsole 开发环境使用的控制台格式，输出到终端时着色，设置 NO_COLOR 环境变量时关闭
+	FormatConsole Format = "console"
+	// FormatLogfmt 单行 key=value 格式，值只在需要时加引号
+	FormatLogfmt Format = "logfmt"
+	// FormatECS 符合 Elastic Common Schema 的 JSON 格式
+	FormatECS Format = "ecs"
+)
+
+// shared 返回格式是否由 xlog 的编码器实现，所有后端输出一致
+func (f Format) shared() bool {
+	switch f {
+	case FormatConsole, FormatLogfmt, FormatECS:
+		return true
+	}
+	return false
+}
+
+// Config represents the xlog configuration
+type Config struct {
+	Backend Backend `yaml:"backend"`
+	Level   Level   `yaml:"level"`
+	Format  Format  `yaml:"format"`
+	Caller  bool    `yaml:"caller"`
+	// Outputs 日志输出目标，为空时输出到 os.Stdout
+	Outputs []io.Writer `yaml:"-"`
+	// Sinks 多路输出，设置后忽略 Format 与 Outputs
+	Sinks []Sink `yaml:"sinks"`
+	// Sampling 与 Dedup 作用于未单独配置的 Sink
+	Sampling *Sampling `yaml:"sampling"`
+	Dedup    *Dedup    `yaml:"dedup"`
+	// Async 作用于未单独配置的 Sink
+	Async *AsyncConfig `yaml:"async"`
+	// Redaction 脱敏配置，为空时使用 DefaultRedaction
+	Redaction *Redaction `yaml:"redaction"`
+	// AtomicLevel 运行时级别控制，可在多个 Logger 间共享；为空时按 Level 新建
+	AtomicLevel *AtomicLevel `yaml:"-"`
+	// Metrics 不为空时统计日志条数与各 Sink 的输出，可在多个 Logger 间共享
+	Metrics *Metrics `yaml:"-"`
+	// Hooks 所有后端共用的日志钩子，只对达到 Level 的日志调用
+	Hooks []Hook `yaml:"-"`
+}
+
+// Sink 独立的日志输出目标，拥有自己的级别、格式与过滤条件
+//
+// Config.Level 决定日志是否产生，Sink.Level 决定该输出是否写入
+type Sink struct {
+	// Name 用于 Metrics 区分输出，为空时使用 Sink 的序号
+	Name   string    `yaml:"name"`
+	Writer io.Writer `yaml:"-"`
+	// Level 该输出的最低级别
+	Level  Level  `yaml:"level"`
+	Format Format `yaml:"format"`
+	// Filter 返回 false 时该输出丢弃此条日志
+	Filter func(Entry) bool `yaml:"-"`
+	// Async 不为空时 Writer 由 AsyncWriter 包装，异步批量写出
+	Async *AsyncConfig `yaml:"async"`
+	// Sampling 采样配置，为空时使用 Config.Sampling
+	Sampling *Sampling `yaml:"sampling"`
+	// Dedup 重复日志合并配置，为空时使用 Config.Dedup
+	Dedup *Dedup `yaml:"dedup"`
+}
+
+// isPlain 没有过滤、采样与合并且不按级别写入的 Sink 可以直接使用后端自身的输出
+func (s Sink) isPlain() bool {
+	_, ok := s.Writer.(LevelWriter)
+	return s.Filter == nil && s.Sampling == nil && s.Dedup == nil && !ok
+}
+
+// levelWriter 返回 Sink 按级别写入的函数，Writer 未实现 LevelWriter 时返回 nil
+func (s Sink) levelWriter() func(Level, []byte) (int, error) {
+	if w, ok := s.Writer.(LevelWriter); ok {
+		return w.WriteLevel
+	}
+	return nil
+}
+
+// DefaultConfig returns a Config with default values
+func DefaultConfig() Config {
+	return Config{
+		Backend: BackendLogrus,
+		Level:   InfoLevel,
+		Format:  FormatText,
+		Caller:  true,
+	}
+}
+
+// New creates a Logger with the backend selected by config.
+func New(config Config) (Logger, error) {
+	sinks := append([]Sink(nil), config.Sinks...)
+	out := &output{}
+	if len(sinks) == 0 {
+		var w io.Writer
+		switch len(config.Outputs) {
+		case 0:
+			w = os.Stdout
+		case 1:
+			w = config.Outputs[0]
+		default:
+			w = io.MultiWriter(config.Outputs...)
+		}
+		sinks = []Sink{{Writer: w, Level: DebugLevel, Format: config.Format}}
+		out.writers = append(out.writers, config.Outputs...)
+	}
+	for i, sink := range sinks {
+		if sink.Sampling == nil {
+			sinks[i].Sampling = config.Sampling
+		}
+		if sink.Dedup == nil {
+			sinks[i].Dedup = config.Dedup
+		}
+		switch sink.Format {
+		case "", FormatText, FormatJSON, FormatConsole, FormatLogfmt, FormatECS:
+		default:
+			return nil, fmt.Errorf("xlog: unknown format %q", sink.Format)
+		}
+		if sink.Writer == nil {
+			return nil, fmt.Errorf("xlog: sink writer cannot be nil")
+		}
+		if sink.Async == nil {
+			sinks[i].Async = config.Async
+		}
+		if len(config.Sinks) > 0 {
+			out.writers = append(out.writers, sink.Writer)
+		}
+	}
+	// 统计包装在 AsyncWriter 之内，记录实际写出的结果
+	if config.Metrics != nil {
+		for i, sink := range sinks {
+			sinks[i].Writer = config.Metrics.wrap(sinkName(sink, i), sink.Writer)
+		}
+	}
+	// AsyncWriter 需要先于原 Writer 关闭
+	var asyncWriters []io.Writer
+	for i, sink := range sinks {
+		if sink.Async != nil {
+			sinks[i].Writer = NewAsyncWriter(sink.Writer, *sink.Async)
+			asyncWriters = append(asyncWriters, sinks[i].Writer)
+		}
+		if config.Metrics != nil {
+			config.Metrics.watchDropped(sinkName(sink, i), sinks[i].Writer)
+		}
+	}
+	out.writers = append(asyncWriters, out.writers...)
+
+	var c core
+	switch config.Backend {
+	case "", BackendLogrus:
+		c = newLogrusCore(config, sinks)
+	case BackendZap:
+		c = newZapCore(config, sinks)
+	case BackendSlog:
+		c = newSlogCore(config, sinks)
+		if len(config.Hooks) > 0 {
+			c = &hookCore{core: c, hooks: config.Hooks}
+		}
+	case BackendNative:
+		c = newNativeCore(config, sinks)
+		if len(config.Hooks) > 0 {
+			c = &hookCore{core: c, hooks: config.Hooks}
+		}
+	default:
+		for _, w := range asyncWriters {
+			_ = closeWriter(w)
+		}
+		return nil, fmt.Errorf("xlog: unknown backend %q", config.Backend)
+	}
+
+	level := config.AtomicLevel
+	if level == nil {
+		level = NewAtomicLevel(config.Level)
+	}
+	return &logger{
+		core:     c,
+		level:    level,
+		output:   out,
+		redactor: newRedactor(config.Redaction),
+		caller:   config.Caller,
+		metrics:  config.Metrics,
+		counters: config.Metrics.logger(""),
+	}, nil
+}
+
+// Init creates a Logger from config and installs it as the global logger.
+func Init(config Config) error {
+	logger, err := New(config)
+	if err != nil {
+		return err
+	}
+	MustSetLogger(logger)
+	return nil
+}
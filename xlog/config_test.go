@@ -0,0 +1,65 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := New(Config{Backend: backend, Level: WarnLevel, Format: FormatJSON, Outputs: []io.Writer{&buf}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Info("dropped")
+			l.With(Any("k", "v")).Warnf("hello %s", backend)
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("expected one json entry, got %q: %v", buf.String(), err)
+			}
+			if entry["msg"] != "hello "+string(backend) || entry["k"] != "v" {
+				t.Errorf("unexpected entry: %v", entry)
+			}
+			if l.Enabled(InfoLevel) || !l.Enabled(ErrorLevel) {
+				t.Errorf("unexpected enabled levels")
+			}
+		})
+	}
+}
+
+func TestNewUnknownBackend(t *testing.T) {
+	if _, err := New(Config{Backend: "log4j"}); err == nil {
+		t.Fatal("expected error for unknown backend")
+	}
+}
+
+func TestMustSetLoggerConcurrent(t *testing.T) {
+	prev := L()
+	defer MustSetLogger(prev)
+	if err := Init(Config{Outputs: []io.Writer{io.Discard}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Infof("message %d", j)
+			}
+		}()
+	}
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendLogrus} {
+		if err := Init(Config{Backend: backend, Outputs: []io.Writer{io.Discard}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
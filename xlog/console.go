@@ -0,0 +1,289 @@
+package xlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rabbit-rm/xgo/internal/buffer"
+	"github.com/rabbit-rm/xgo/internal/caller"
+)
+
+const (
+	consoleTimeLayout = "2006-01-02 15:04:05.000"
+	// consoleCallerWidth 调用位置列的最小宽度，更长的路径不截断
+	consoleCallerWidth = 28
+	consoleIndent      = "    "
+)
+
+const (
+	colorReset   = "\x1b[0m"
+	colorDim     = "\x1b[90m"
+	colorBold    = "\x1b[1m"
+	colorRed     = "\x1b[31m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+	colorAlert   = "\x1b[1;97;41m"
+)
+
+// consoleEncoder 开发环境使用的控制台格式，所有后端共用：
+// 时间、级别与调用位置按列对齐，多行文本、堆栈与嵌套字段在日志行下方缩进输出，
+// color 为 true 时按级别着色
+type consoleEncoder struct {
+	color bool
+}
+
+// newConsoleEncoder 根据 Sink 的 Writer 决定是否着色
+func newConsoleEncoder(sink Sink) consoleEncoder {
+	return consoleEncoder{color: useColor(sink.Writer)}
+}
+
+func (enc consoleEncoder) encode(buf *buffer.Buffer, e nativeEntry) {
+	enc.paint(buf, colorDim, func() {
+		buf.AppendTime(e.time, consoleTimeLayout)
+	})
+	buf.AppendByte(' ')
+	enc.paint(buf, levelColor(e.level), func() {
+		buf.AppendString(levelBadge(e.level))
+	})
+	if e.frame != nil {
+		var scratch [256]byte
+		file := caller.AppendPretty(scratch[:0], e.frame.File, e.frame.Line)
+		buf.AppendByte(' ')
+		enc.paint(buf, colorDim, func() {
+			buf.AppendBytes(file)
+		})
+		// 填充放在颜色之外，保证着色与否列宽一致
+		for i := utf8.RuneCount(file); i < consoleCallerWidth; i++ {
+			buf.AppendByte(' ')
+		}
+	}
+
+	type block struct {
+		key   string
+		lines []string
+	}
+	var blocks []block
+	fields := e.fields
+	if len(e.with) > 0 {
+		fields = append(e.with[:len(e.with):len(e.with)], e.fields...)
+	}
+	for _, f := range fields {
+		if f.Key == loggerKey {
+			buf.AppendByte(' ')
+			enc.paint(buf, colorBold, func() {
+				buf.AppendByte('[')
+				enc.appendValue(buf, f.Value)
+				buf.AppendByte(']')
+			})
+		}
+	}
+	buf.AppendByte(' ')
+	appendConsoleMessage(buf, e.msg)
+	for _, f := range fields {
+		if f.Key == loggerKey {
+			continue
+		}
+		if lines := consoleBlock(f.Key, f.Value); lines != nil {
+			blocks = append(blocks, block{key: f.Key, lines: lines})
+			continue
+		}
+		buf.AppendByte(' ')
+		enc.paint(buf, colorCyan, func() {
+			buf.AppendString(f.Key)
+		})
+		buf.AppendByte('=')
+		enc.appendValue(buf, f.Value)
+	}
+	if e.repeated > 0 {
+		buf.AppendByte(' ')
+		enc.paint(buf, colorCyan, func() {
+			buf.AppendString("repeated")
+		})
+		buf.AppendByte('=')
+		buf.AppendInt(int64(e.repeated))
+	}
+	buf.AppendByte('\n')
+
+	for _, b := range blocks {
+		buf.AppendString(consoleIndent)
+		enc.paint(buf, colorCyan, func() {
+			buf.AppendString(b.key)
+		})
+		buf.AppendString(":\n")
+		for _, line := range b.lines {
+			buf.AppendString(consoleIndent + consoleIndent)
+			buf.AppendString(line)
+			buf.AppendByte('\n')
+		}
+	}
+}
+
+// appendFields 控制台格式需要完整的字段来决定行内与多行输出，With 字段通过 nativeEntry.with 传入
+func (consoleEncoder) appendFields(*buffer.Buffer, []Field) {}
+
+// paint 在 color 开启时用 ANSI 颜色包裹 write 写入的内容
+func (enc consoleEncoder) paint(buf *buffer.Buffer, color string, write func()) {
+	if !enc.color {
+		write()
+		return
+	}
+	buf.AppendString(color)
+	write()
+	buf.AppendString(colorReset)
+}
+
+func (consoleEncoder) appendValue(buf *buffer.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		appendConsoleString(buf, v)
+	case time.Time:
+		buf.AppendTime(v, time.RFC3339Nano)
+	case time.Duration:
+		buf.AppendString(v.String())
+	case error:
+		appendConsoleString(buf, v.Error())
+	case fmt.Stringer:
+		appendConsoleString(buf, v.String())
+	default:
+		if data, ok := consoleJSON(v); ok {
+			buf.AppendBytes(data)
+			return
+		}
+		appendTextValue(buf, v)
+	}
+}
+
+// appendConsoleMessage 转义消息中的换行、ANSI 转义等控制字符，保证每条日志只占一行且不改变终端状态
+func appendConsoleMessage(buf *buffer.Buffer, s string) {
+	start := 0
+	for i, r := range s {
+		if !unicode.IsControl(r) {
+			continue
+		}
+		buf.AppendString(s[start:i])
+		q := strconv.QuoteRune(r)
+		buf.AppendString(q[1 : len(q)-1])
+		start = i + utf8.RuneLen(r)
+	}
+	buf.AppendString(s[start:])
+}
+
+// appendConsoleString 只在包含空白、引号、等号或控制字符时加引号
+func appendConsoleString(buf *buffer.Buffer, s string) {
+	if s == "" || strings.ContainsFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '"' || r == '=' || r == utf8.RuneError || r == 0x7f
+	}) {
+		buf.AppendQuote(s)
+		return
+	}
+	buf.AppendString(s)
+}
+
+// consoleBlock 返回需要在日志行下方逐行输出的内容，返回 nil 时在行内输出：
+// 多行文本、error.stack 与 error.chain 每项一行，嵌套结构按缩进的 JSON 输出
+func consoleBlock(key string, value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if strings.Contains(strings.TrimRight(v, "\n"), "\n") {
+			return strings.Split(strings.TrimRight(v, "\n"), "\n")
+		}
+		return nil
+	case error, fmt.Stringer, time.Time:
+		return nil
+	}
+	if strings.HasSuffix(key, ".stack") || strings.HasSuffix(key, ".chain") {
+		if lines := stringLines(value); lines != nil {
+			return lines
+		}
+	}
+	if !isNested(value) {
+		return nil
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil || !strings.Contains(string(data), "\n") {
+		return nil
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// stringLines 将 []string 或 zap 编码后的 []interface{} 转换为逐行输出的内容
+func stringLines(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		lines := make([]string, 0, len(v))
+		for _, item := range v {
+			lines = append(lines, fmt.Sprint(item))
+		}
+		return lines
+	}
+	return nil
+}
+
+// consoleJSON 嵌套结构在行内按紧凑的 JSON 输出
+func consoleJSON(value interface{}) ([]byte, bool) {
+	if !isNested(value) {
+		return nil, false
+	}
+	data, err := json.Marshal(value)
+	return data, err == nil
+}
+
+func isNested(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	t := reflect.TypeOf(value)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Struct, reflect.Array:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// levelBadge 等宽的级别标记
+func levelBadge(level Level) string {
+	switch level {
+	case DebugLevel:
+		return "DEBUG"
+	case InfoLevel:
+		return "INFO "
+	case WarnLevel:
+		return "WARN "
+	case ErrorLevel:
+		return "ERROR"
+	case PanicLevel:
+		return "PANIC"
+	default:
+		return "FATAL"
+	}
+}
+
+func levelColor(level Level) string {
+	switch level {
+	case DebugLevel:
+		return colorMagenta
+	case InfoLevel:
+		return colorBlue
+	case WarnLevel:
+		return colorYellow
+	case ErrorLevel:
+		return colorRed
+	default:
+		return colorAlert
+	}
+}
@@ -0,0 +1,69 @@
+package xlog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rabbit-rm/xgo/internal/buffer"
+)
+
+func TestConsole(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			l, err := New(Config{Backend: backend, Format: FormatConsole, Caller: true, Outputs: []io.Writer{&out}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l = l.Named("demo").With(Any("request", "r-1"))
+			l.Info("short")
+			l.Log(WarnLevel, "nested", Any("config", map[string]int{"a": 1, "b": 2}), Any("text", "line1\nline2"))
+
+			lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			if strings.Contains(out.String(), "\x1b[") {
+				t.Fatalf("non-terminal output must not be colored: %q", out.String())
+			}
+			if len(lines) < 2 || strings.Index(lines[0], "[demo]") != strings.Index(lines[1], "[demo]") {
+				t.Fatalf("columns are not aligned:\n%s", out.String())
+			}
+			for _, want := range []string{"INFO  ", "WARN  ", "[demo] short request=r-1", "    config:\n", "        line2\n"} {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected %q in:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestConsoleColor(t *testing.T) {
+	if useColor(&bytes.Buffer{}) {
+		t.Error("color must be disabled for non-terminal writers")
+	}
+	t.Setenv("NO_COLOR", "")
+	if !colorEnabled(true) {
+		t.Error("color must be enabled for terminals")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled(true) {
+		t.Error("color must be disabled when NO_COLOR is set")
+	}
+
+	buf := buffer.NewPool().Get()
+	defer buf.Free()
+	consoleEncoder{color: true}.encode(buf, nativeEntry{time: time.Now(), level: ErrorLevel, msg: "boom"})
+	if !strings.Contains(buf.String(), colorRed+"ERROR"+colorReset) {
+		t.Errorf("expected colored level badge, got %q", buf.String())
+	}
+}
+
+func TestConsoleMessageEscape(t *testing.T) {
+	buf := buffer.NewPool().Get()
+	defer buf.Free()
+	consoleEncoder{}.encode(buf, nativeEntry{time: time.Now(), level: InfoLevel, msg: "line1\nline2 \x1b[31mred\x1b[0m 中文\t"})
+	if want := ` line1\nline2 \x1b[31mred\x1b[0m 中文\t` + "\n"; !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("got %q, want suffix %q", buf.String(), want)
+	}
+}
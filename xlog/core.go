@@ -0,0 +1,292 @@
+package xlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/rabbit-rm/xgo/internal/caller"
+)
+
+// core 由各日志后端实现，只负责编码与输出；
+// 级别判断、格式化与 Fatal 退出由 logger 统一处理，保证不同后端行为一致
+type core interface {
+	// Log 写入一条日志，PanicLevel 时不能 panic，FatalLevel 时不能退出进程；
+	// template 为 printf 风格调用的格式串，用于采样，其他调用为空；
+	// frame 为 logger 解析的调用方，未开启 Caller 时为 nil；
+	// t 为日志产生的时间，Buffered 缓存的日志写出时仍使用原来的时间
+	Log(t time.Time, level Level, template, msg string, fields []Field, frame *runtime.Frame)
+	With(fields []Field) core
+	// Sync 输出后端内部缓存的日志，如等待合并的重复日志汇总
+	Sync() error
+}
+
+// logger 是 New 返回的 Logger 实现
+type logger struct {
+	core     core
+	level    *AtomicLevel
+	name     string
+	output   *output
+	redactor *redactor
+	// caller 是否解析调用方，callerSkip 为找到调用方后额外跳过的帧数
+	caller     bool
+	callerSkip int
+	// counters 为 logger 名称对应的计数器，未开启 Metrics 时为 nil
+	metrics  *Metrics
+	counters *entryCounters
+	// scope 为 Buffered 创建的缓存，由子 logger 共享
+	scope *bufferScope
+}
+
+// output 记录 logger 及其子 logger 共享的输出，用于 Sync 与 Close
+type output struct {
+	writers []io.Writer
+	once    sync.Once
+	err     error
+}
+
+// AtomicLevel returns the level controller shared by the logger and its children.
+func (l *logger) AtomicLevel() *AtomicLevel {
+	return l.level
+}
+
+// Metrics returns the metrics shared by the logger and its children, nil if not enabled.
+func (l *logger) Metrics() *Metrics {
+	return l.metrics
+}
+
+func (l *logger) Debug(args ...interface{}) {
+	l.print(DebugLevel, args)
+}
+
+func (l *logger) Info(args ...interface{}) {
+	l.print(InfoLevel, args)
+}
+
+func (l *logger) Warn(args ...interface{}) {
+	l.print(WarnLevel, args)
+}
+
+func (l *logger) Error(args ...interface{}) {
+	l.print(ErrorLevel, args)
+}
+
+func (l *logger) Panic(args ...interface{}) {
+	l.print(PanicLevel, args)
+	panic(fmt.Sprint(args...))
+}
+
+func (l *logger) Fatal(args ...interface{}) {
+	l.print(FatalLevel, args)
+	l.exit()
+}
+
+func (l *logger) Debugf(format string, args ...interface{}) {
+	l.printf(DebugLevel, format, args)
+}
+
+func (l *logger) Infof(format string, args ...interface{}) {
+	l.printf(InfoLevel, format, args)
+}
+
+func (l *logger) Warnf(format string, args ...interface{}) {
+	l.printf(WarnLevel, format, args)
+}
+
+func (l *logger) Errorf(format string, args ...interface{}) {
+	l.printf(ErrorLevel, format, args)
+}
+
+func (l *logger) Panicf(format string, args ...interface{}) {
+	l.printf(PanicLevel, format, args)
+	panic(fmt.Sprintf(format, args...))
+}
+
+func (l *logger) Fatalf(format string, args ...interface{}) {
+	l.printf(FatalLevel, format, args)
+	l.exit()
+}
+
+func (l *logger) Debugfn(fn func() string) {
+	l.printfn(DebugLevel, fn)
+}
+
+func (l *logger) Infofn(fn func() string) {
+	l.printfn(InfoLevel, fn)
+}
+
+func (l *logger) Warnfn(fn func() string) {
+	l.printfn(WarnLevel, fn)
+}
+
+func (l *logger) Errorfn(fn func() string) {
+	l.printfn(ErrorLevel, fn)
+}
+
+func (l *logger) Log(level Level, msg string, fields ...Field) {
+	if l.accept(level) {
+		l.write(level, "", l.redactor.text(msg), l.named(l.redactor.fields(expandErrors(ResolveFields(fields), l.redactor))), l.frame())
+	}
+	l.terminate(level, msg)
+}
+
+func (l *logger) Logfn(level Level, msg string, fn func() []Field) {
+	if l.accept(level) {
+		l.write(level, "", l.redactor.text(msg), l.named(l.redactor.fields(expandErrors(ResolveFields(fn()), l.redactor))), l.frame())
+	}
+	l.terminate(level, msg)
+}
+
+// logAt 与 Log 相同但使用记录产生的时间，供 SlogHandler 转发 slog.Record，不会 panic 或退出
+func (l *logger) logAt(t time.Time, level Level, msg string, fields []Field) {
+	if l.accept(level) {
+		l.writeAt(t, level, "", l.redactor.text(msg), l.named(l.redactor.fields(expandErrors(ResolveFields(fields), l.redactor))), l.frame())
+	}
+}
+
+// terminate 在 PanicLevel 与 FatalLevel 日志写入后 panic 或退出
+func (l *logger) terminate(level Level, msg string) {
+	switch level {
+	case PanicLevel:
+		panic(msg)
+	case FatalLevel:
+		l.exit()
+	}
+}
+
+func (l *logger) With(fields ...Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	child := *l
+	child.core = l.core.With(l.redactor.fields(expandErrors(ResolveFields(fields), l.redactor)))
+	return &child
+}
+
+func (l *logger) Named(name string) Logger {
+	if name == "" {
+		return l
+	}
+	child := *l
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	child.name = name
+	child.counters = l.metrics.logger(name)
+	return &child
+}
+
+func (l *logger) WithCallerSkip(skip int) Logger {
+	child := *l
+	child.callerSkip += skip
+	return &child
+}
+
+func (l *logger) Enabled(level Level) bool {
+	return l.level.enabled(level, l.name)
+}
+
+func (l *logger) Sync() error {
+	errs := []error{l.core.Sync()}
+	for _, w := range l.output.writers {
+		errs = append(errs, syncWriter(w))
+	}
+	return errors.Join(errs...)
+}
+
+func (l *logger) Close() error {
+	l.output.once.Do(func() {
+		errs := []error{l.core.Sync()}
+		for _, w := range l.output.writers {
+			errs = append(errs, syncWriter(w), closeWriter(w))
+		}
+		l.output.err = errors.Join(errs...)
+	})
+	return l.output.err
+}
+
+// exit 在 Fatal 日志之后执行退出钩子，输出缓冲的日志并退出进程
+func (l *logger) exit() {
+	RunExitHooks()
+	_ = l.Sync()
+	os.Exit(1)
+}
+
+func (l *logger) print(level Level, args []interface{}) {
+	if l.accept(level) {
+		args, fields := l.args(args)
+		l.write(level, "", l.redactor.text(fmt.Sprint(args...)), l.named(fields), l.frame())
+	}
+}
+
+func (l *logger) printf(level Level, format string, args []interface{}) {
+	if l.accept(level) {
+		args, fields := l.args(args)
+		l.write(level, format, l.redactor.text(fmt.Sprintf(format, args...)), l.named(fields), l.frame())
+	}
+}
+
+// accept 判断日志是否需要产生：级别已开启，或者会被 Buffered 缓存
+func (l *logger) accept(level Level) bool {
+	return l.Enabled(level) || l.scope.buffers(level)
+}
+
+// write 将日志交给后端，低于缓存阈值且级别未开启的日志先放入 Buffered 的缓存
+func (l *logger) write(level Level, template, msg string, fields []Field, frame *runtime.Frame) {
+	l.writeAt(time.Now(), level, template, msg, fields, frame)
+}
+
+func (l *logger) writeAt(t time.Time, level Level, template, msg string, fields []Field, frame *runtime.Frame) {
+	entry := bufferedEntry{l: l, time: t, level: level, template: template, msg: msg, fields: fields, frame: frame}
+	if l.scope != nil && l.scope.hold(entry, l.Enabled(level)) {
+		return
+	}
+	entry.write()
+}
+
+func (l *logger) count(level Level) {
+	if l.counters != nil {
+		l.counters.inc(level)
+	}
+}
+
+// printfn 只在日志需要输出时调用 fn
+func (l *logger) printfn(level Level, fn func() string) {
+	if l.accept(level) {
+		l.write(level, "", l.redactor.text(fn()), l.named(nil), l.frame())
+	}
+}
+
+// named 在字段前加上 logger 名称，名称在写入时添加以避免嵌套 Named 产生重复字段
+func (l *logger) named(fields []Field) []Field {
+	if l.name == "" {
+		return fields
+	}
+	named := make([]Field, 0, len(fields)+1)
+	named = append(named, Field{Key: loggerKey, Value: l.name})
+	return append(named, fields...)
+}
+
+// frame 解析 logger 之外的调用方，跳过 Helper 标记的函数
+func (l *logger) frame() *runtime.Frame {
+	if !l.caller {
+		return nil
+	}
+	if frame, ok := caller.FrameSkip(1, l.callerSkip); ok {
+		return &frame
+	}
+	return nil
+}
+
+// args 脱敏参数，并将参数中的第一个 error 展开为 error 字段
+func (l *logger) args(args []interface{}) ([]interface{}, []Field) {
+	args, err := errorArgs(l.redactor.args(args))
+	if err == nil {
+		return args, nil
+	}
+	return args, l.redactor.fields(errorFields(errorKey, err, l.redactor))
+}
@@ -0,0 +1,75 @@
+package xlog
+
+import (
+	"strings"
+
+	"github.com/rabbit-rm/xgo/internal/buffer"
+	"github.com/rabbit-rm/xgo/internal/caller"
+	"github.com/rabbit-rm/xgo/xlog/internal/sample"
+)
+
+// ecsVersion 输出的 Elastic Common Schema 版本
+const ecsVersion = "8.11.0"
+
+// ecsEncoder 输出 Elastic Common Schema 的 JSON，字段名采用 ecs-logging 的扁平写法：
+// 固定字段为 @timestamp、log.level、message 与 log.origin.*，
+// error 展开的字段映射为 error.message 与 error.stack_trace，logger 映射为 log.logger
+type ecsEncoder struct{}
+
+func (enc ecsEncoder) encode(buf *buffer.Buffer, e nativeEntry) {
+	buf.AppendString(`{"@timestamp":"`)
+	buf.AppendTime(e.time.UTC(), "2006-01-02T15:04:05.000000Z")
+	buf.AppendString(`","log.level":"`)
+	buf.AppendString(e.level.String())
+	buf.AppendString(`","message":`)
+	appendJSONString(buf, e.msg)
+	buf.AppendString(`,"ecs.version":"` + ecsVersion + `"`)
+	if e.frame != nil {
+		buf.AppendString(`,"log.origin.file.name":`)
+		appendJSONString(buf, caller.RelFile(e.frame.File))
+		buf.AppendString(`,"log.origin.file.line":`)
+		buf.AppendInt(int64(e.frame.Line))
+		if e.frame.Function != "" {
+			buf.AppendString(`,"log.origin.function":`)
+			appendJSONString(buf, e.frame.Function)
+		}
+	}
+	buf.AppendBytes(e.context)
+	enc.appendFields(buf, e.fields)
+	if e.repeated > 0 {
+		buf.AppendString(`,"` + sample.RepeatedKey + `":`)
+		buf.AppendInt(int64(e.repeated))
+	}
+	buf.AppendString("}\n")
+}
+
+func (ecsEncoder) appendFields(buf *buffer.Buffer, fields []Field) {
+	for _, f := range fields {
+		key := ecsKey(f.Key)
+		buf.AppendByte(',')
+		appendJSONString(buf, key)
+		buf.AppendByte(':')
+		if key == "error.stack_trace" {
+			if lines := stringLines(f.Value); lines != nil {
+				appendJSONString(buf, strings.Join(lines, "\n"))
+				continue
+			}
+		}
+		appendJSONValue(buf, f.Value)
+	}
+}
+
+// ecsKey 将 xlog 的字段名映射为 ECS 字段名，与固定字段同名的字段加上 fields. 前缀，其余字段保持不变
+func ecsKey(key string) string {
+	switch key {
+	case "@timestamp", "log.level", "message", "ecs.version":
+		return "fields." + key
+	case errorKey:
+		return "error.message"
+	case errorKey + ".stack":
+		return "error.stack_trace"
+	case loggerKey:
+		return "log.logger"
+	}
+	return key
+}
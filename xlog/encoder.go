@@ -0,0 +1,323 @@
+package xlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rabbit-rm/xgo/internal/buffer"
+	"github.com/rabbit-rm/xgo/internal/caller"
+	"github.com/rabbit-rm/xgo/xlog/internal/sample"
+)
+
+// nativeTimeLayout 与其他后端保持一致的时间格式
+const nativeTimeLayout = "2006-01-02T15:04:05"
+
+// 与 logrus 默认输出保持一致的固定字段名
+const (
+	nativeTimeKey   = "time"
+	nativeLevelKey  = "level"
+	nativeMsgKey    = "msg"
+	nativeCallerKey = "file"
+)
+
+// nativeEntry native 后端的日志条目
+type nativeEntry struct {
+	time   time.Time
+	level  Level
+	msg    string
+	frame  *runtime.Frame
+	fields []Field
+	// with 为 With 添加的字段，只有控制台格式使用
+	with []Field
+	// context 为 With 字段按 Sink 格式预先编码的结果
+	context []byte
+	// repeated 为合并的重复次数，大于 0 时输出 repeated 字段
+	repeated int
+}
+
+// encoder 将日志直接编码到 buffer，避免中间的 map 与格式化字符串
+type encoder interface {
+	// encode 写入一行完整的日志，包括结尾的换行
+	encode(buf *buffer.Buffer, e nativeEntry)
+	// appendFields 写入字段，结果可以直接拼接在 encode 的固定字段之后
+	appendFields(buf *buffer.Buffer, fields []Field)
+}
+
+func newEncoder(sink Sink) encoder {
+	switch sink.Format {
+	case FormatJSON:
+		return jsonEncoder{}
+	case FormatConsole:
+		return newConsoleEncoder(sink)
+	case FormatLogfmt:
+		return logfmtEncoder{}
+	case FormatECS:
+		return ecsEncoder{}
+	default:
+		return textEncoder{}
+	}
+}
+
+// entryFrame 由后端提供的调用位置构造 frame，file 为空时返回 nil
+func entryFrame(file string, line int, function string) *runtime.Frame {
+	if file == "" {
+		return nil
+	}
+	return &runtime.Frame{File: file, Line: line, Function: function}
+}
+
+// textEncoder 输出 key="value" 形式的文本，与 logrus 的文本格式一致
+type textEncoder struct{}
+
+func (enc textEncoder) encode(buf *buffer.Buffer, e nativeEntry) {
+	buf.AppendString(nativeTimeKey + `="`)
+	buf.AppendTime(e.time, nativeTimeLayout)
+	buf.AppendString(`" ` + nativeLevelKey + `="`)
+	buf.AppendString(e.level.String())
+	buf.AppendString(`" ` + nativeMsgKey + `=`)
+	buf.AppendQuote(e.msg)
+	if e.frame != nil {
+		buf.AppendString(" " + nativeCallerKey + "=")
+		appendQuotedCaller(buf, e.frame)
+	}
+	buf.AppendBytes(e.context)
+	enc.appendFields(buf, e.fields)
+	if e.repeated > 0 {
+		buf.AppendString(" " + sample.RepeatedKey + "=")
+		buf.AppendInt(int64(e.repeated))
+	}
+	buf.AppendByte('\n')
+}
+
+func (textEncoder) appendFields(buf *buffer.Buffer, fields []Field) {
+	for _, f := range fields {
+		buf.AppendByte(' ')
+		buf.AppendString(fieldKey(f.Key))
+		buf.AppendByte('=')
+		appendTextValue(buf, f.Value)
+	}
+}
+
+func appendTextValue(buf *buffer.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		buf.AppendQuote(v)
+	case bool:
+		buf.AppendBool(v)
+	case int:
+		buf.AppendInt(int64(v))
+	case int8:
+		buf.AppendInt(int64(v))
+	case int16:
+		buf.AppendInt(int64(v))
+	case int32:
+		buf.AppendInt(int64(v))
+	case int64:
+		buf.AppendInt(v)
+	case uint:
+		buf.AppendUint(uint64(v))
+	case uint8:
+		buf.AppendUint(uint64(v))
+	case uint16:
+		buf.AppendUint(uint64(v))
+	case uint32:
+		buf.AppendUint(uint64(v))
+	case uint64:
+		buf.AppendUint(v)
+	case float32:
+		buf.AppendFloat(float64(v), 32)
+	case float64:
+		buf.AppendFloat(v, 64)
+	case time.Time:
+		buf.AppendByte('"')
+		buf.AppendTime(v, time.RFC3339Nano)
+		buf.AppendByte('"')
+	case time.Duration:
+		buf.AppendQuote(v.String())
+	case error:
+		buf.AppendQuote(v.Error())
+	case fmt.Stringer:
+		buf.AppendQuote(v.String())
+	default:
+		buf.AppendQuote(fmt.Sprint(v))
+	}
+}
+
+// jsonEncoder 每行输出一个 JSON 对象
+type jsonEncoder struct{}
+
+func (enc jsonEncoder) encode(buf *buffer.Buffer, e nativeEntry) {
+	buf.AppendString(`{"` + nativeTimeKey + `":"`)
+	buf.AppendTime(e.time, nativeTimeLayout)
+	buf.AppendString(`","` + nativeLevelKey + `":"`)
+	buf.AppendString(e.level.String())
+	buf.AppendString(`","` + nativeMsgKey + `":`)
+	appendJSONString(buf, e.msg)
+	if e.frame != nil {
+		buf.AppendString(`,"` + nativeCallerKey + `":`)
+		appendQuotedCaller(buf, e.frame)
+	}
+	buf.AppendBytes(e.context)
+	enc.appendFields(buf, e.fields)
+	if e.repeated > 0 {
+		buf.AppendString(`,"` + sample.RepeatedKey + `":`)
+		buf.AppendInt(int64(e.repeated))
+	}
+	buf.AppendString("}\n")
+}
+
+func (jsonEncoder) appendFields(buf *buffer.Buffer, fields []Field) {
+	for _, f := range fields {
+		buf.AppendByte(',')
+		appendJSONString(buf, fieldKey(f.Key))
+		buf.AppendByte(':')
+		appendJSONValue(buf, f.Value)
+	}
+}
+
+func appendJSONValue(buf *buffer.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		buf.AppendString("null")
+	case string:
+		appendJSONString(buf, v)
+	case bool:
+		buf.AppendBool(v)
+	case int:
+		buf.AppendInt(int64(v))
+	case int8:
+		buf.AppendInt(int64(v))
+	case int16:
+		buf.AppendInt(int64(v))
+	case int32:
+		buf.AppendInt(int64(v))
+	case int64:
+		buf.AppendInt(v)
+	case uint:
+		buf.AppendUint(uint64(v))
+	case uint8:
+		buf.AppendUint(uint64(v))
+	case uint16:
+		buf.AppendUint(uint64(v))
+	case uint32:
+		buf.AppendUint(uint64(v))
+	case uint64:
+		buf.AppendUint(v)
+	case float32:
+		appendJSONFloat(buf, float64(v), 32)
+	case float64:
+		appendJSONFloat(buf, v, 64)
+	case time.Time:
+		buf.AppendByte('"')
+		buf.AppendTime(v, time.RFC3339Nano)
+		buf.AppendByte('"')
+	case time.Duration:
+		appendJSONString(buf, v.String())
+	case json.Marshaler:
+		appendJSONMarshal(buf, v)
+	case error:
+		appendJSONString(buf, v.Error())
+	case fmt.Stringer:
+		appendJSONString(buf, v.String())
+	default:
+		appendJSONMarshal(buf, v)
+	}
+}
+
+// appendJSONFloat NaN 与 ±Inf 不是合法的 JSON 数字，按字符串输出
+func appendJSONFloat(buf *buffer.Buffer, f float64, bitSize int) {
+	switch {
+	case math.IsNaN(f):
+		buf.AppendString(`"NaN"`)
+	case math.IsInf(f, 1):
+		buf.AppendString(`"+Inf"`)
+	case math.IsInf(f, -1):
+		buf.AppendString(`"-Inf"`)
+	default:
+		buf.AppendFloat(f, bitSize)
+	}
+}
+
+// appendJSONMarshal 其他类型交给 encoding/json，失败时退化为 fmt 格式
+func appendJSONMarshal(buf *buffer.Buffer, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		appendJSONString(buf, fmt.Sprint(v))
+		return
+	}
+	buf.AppendBytes(data)
+}
+
+// fieldKey 与固定字段同名的字段加上 fields. 前缀，与 logrus 的处理一致
+func fieldKey(key string) string {
+	switch key {
+	case nativeTimeKey, nativeLevelKey, nativeMsgKey, nativeCallerKey:
+		return "fields." + key
+	}
+	return key
+}
+
+// appendQuotedCaller 写入带引号并转义的 "file:line"，文件为相对于工作目录的路径
+func appendQuotedCaller(buf *buffer.Buffer, frame *runtime.Frame) {
+	buf.AppendByte('"')
+	appendJSONEscaped(buf, caller.RelFile(frame.File))
+	buf.AppendByte(':')
+	buf.AppendInt(int64(frame.Line))
+	buf.AppendByte('"')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString 写入带引号并转义的 JSON 字符串，非法的 UTF-8 替换为 �
+func appendJSONString(buf *buffer.Buffer, s string) {
+	buf.AppendByte('"')
+	appendJSONEscaped(buf, s)
+	buf.AppendByte('"')
+}
+
+// appendJSONEscaped 写入转义后的 JSON 字符串内容，不含引号
+func appendJSONEscaped(buf *buffer.Buffer, s string) {
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf.AppendString(s[start:i])
+			switch c {
+			case '"', '\\':
+				buf.AppendByte('\\')
+				buf.AppendByte(c)
+			case '\n':
+				buf.AppendString(`\n`)
+			case '\r':
+				buf.AppendString(`\r`)
+			case '\t':
+				buf.AppendString(`\t`)
+			default:
+				buf.AppendString(`\u00`)
+				buf.AppendByte(hexDigits[c>>4])
+				buf.AppendByte(hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.AppendString(s[start:i])
+			buf.AppendString(`�`)
+			i++
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.AppendString(s[start:])
+}
@@ -0,0 +1,16 @@
+package xlog
+
+import (
+	"runtime"
+	"time"
+)
+
+// Entry 后端无关的日志条目
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  []Field
+	// Caller 为日志的调用方，未开启 Caller 时为 nil
+	Caller *runtime.Frame
+}
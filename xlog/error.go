@@ -0,0 +1,160 @@
+package xlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/rabbit-rm/xgo/internal/caller"
+)
+
+// maxStackFrames error.stack 最多输出的帧数
+const maxStackFrames = 16
+
+// errorFielder 由携带结构化上下文的 error 实现，例如 xerror.WithFields
+type errorFielder interface {
+	Fields() map[string]interface{}
+}
+
+// expandErrors 将值为 error 的字段展开，未展开时返回原切片
+func expandErrors(fields []Field, r *redactor) []Field {
+	var expanded []Field
+	for i, f := range fields {
+		err, ok := f.Value.(error)
+		if !ok {
+			if expanded != nil {
+				expanded = append(expanded, f)
+			}
+			continue
+		}
+		if expanded == nil {
+			expanded = append(make([]Field, 0, len(fields)+4), fields[:i]...)
+		}
+		expanded = append(expanded, errorFields(f.Key, err, r)...)
+	}
+	if expanded == nil {
+		return fields
+	}
+	return expanded
+}
+
+// errorFields 将 err 展开为 key、key.code、key.fields、key.stack 与 key.chain，空值不输出；
+// key.fields 中的敏感 key 由 r 脱敏
+func errorFields(key string, err error, r *redactor) []Field {
+	fields := []Field{{Key: key, Value: err.Error()}}
+
+	var coder interface{ Code() gcode.Code }
+	if errors.As(err, &coder) {
+		if code := coder.Code(); code != nil && code.Code() != gcode.CodeNil.Code() {
+			fields = append(fields, Field{Key: key + ".code", Value: code.Code()})
+		}
+	}
+
+	var (
+		values = make(map[string]interface{})
+		chain  []string
+		stack  []string
+	)
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if fielder, ok := e.(errorFielder); ok {
+			for k, v := range fielder.Fields() {
+				if _, ok := values[k]; !ok {
+					values[k] = v
+				}
+			}
+		}
+		if stacker, ok := e.(interface{ Stack() string }); ok && stack == nil {
+			stack = parseStack(stacker.Stack())
+		}
+		// xerror.WithFields 等包装不改变消息，不计入错误链
+		if next := errors.Unwrap(e); next != nil && next.Error() == e.Error() {
+			continue
+		}
+		msg := e.Error()
+		if current, ok := e.(interface{ Current() error }); ok && current.Current() != nil {
+			msg = current.Current().Error()
+		}
+		chain = append(chain, msg)
+	}
+	if len(values) > 0 {
+		for k := range values {
+			if r.sensitive(k) {
+				values[k] = r.mask
+			}
+		}
+		fields = append(fields, Field{Key: key + ".fields", Value: values})
+	}
+	if len(stack) > 0 {
+		fields = append(fields, Field{Key: key + ".stack", Value: stack})
+	}
+	if len(chain) > 1 {
+		fields = append(fields, Field{Key: key + ".chain", Value: chain})
+	}
+	return fields
+}
+
+// parseStack 将 gerror 的堆栈文本转换为 "函数 file:line" 形式的帧列表，
+// gerror 的每一帧由 "N).  函数全名" 与其后的 "文件:行号" 两行组成
+func parseStack(text string) []string {
+	var (
+		frames   []string
+		function string
+	)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, ").  "); i > 0 {
+			function = line[i+4:]
+			continue
+		}
+		if function == "" || !strings.HasPrefix(line, "/") {
+			continue
+		}
+		file, lineNo := line, 0
+		if i := strings.LastIndex(line, ":"); i > 0 {
+			file = line[:i]
+			fmt.Sscan(line[i+1:], &lineNo)
+		}
+		if i := strings.LastIndex(function, "/"); i >= 0 {
+			function = function[i+1:]
+		}
+		frames = append(frames, function+" "+caller.Pretty(file, lineNo))
+		function = ""
+		if len(frames) == maxStackFrames {
+			break
+		}
+	}
+	return frames
+}
+
+// errorArgs 返回参数中的第一个 error，并将所有 error 替换为不输出堆栈的 errorMessage
+func errorArgs(args []interface{}) ([]interface{}, error) {
+	var (
+		replaced []interface{}
+		first    error
+	)
+	for i, arg := range args {
+		err, ok := arg.(error)
+		if !ok {
+			continue
+		}
+		if first == nil {
+			first = err
+			replaced = append([]interface{}(nil), args...)
+		}
+		replaced[i] = errorMessage{err: err}
+	}
+	if first == nil {
+		return args, nil
+	}
+	return replaced, first
+}
+
+// errorMessage 忽略 %+v 的 + 标志，堆栈由 error.stack 字段输出
+type errorMessage struct {
+	err error
+}
+
+func (e errorMessage) Format(s fmt.State, verb rune) {
+	fmt.Fprintf(s, strings.Replace(fmt.FormatString(s, verb), "+", "", 1), e.err)
+}
@@ -0,0 +1,60 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/rabbit-rm/xgo/xerror"
+)
+
+func TestErrorFields(t *testing.T) {
+	inner := gerror.NewCode(gcode.New(1001, "", nil), "record not found")
+	err := xerror.WithFields(xerror.Wrapf(inner, "load user"), map[string]interface{}{"user_id": 42, "password": "hunter2"})
+
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			l, e := New(Config{Backend: backend, Format: FormatJSON, Outputs: []io.Writer{&out}})
+			if e != nil {
+				t.Fatal(e)
+			}
+			l.Log(ErrorLevel, "request failed", Err(err))
+			l.Errorf("request failed: %+v", err)
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("expected 2 lines, got %q", out.String())
+			}
+			for _, line := range lines {
+				var entry map[string]interface{}
+				if e := json.Unmarshal([]byte(line), &entry); e != nil {
+					t.Fatal(e)
+				}
+				if entry["error"] != "load user: record not found" {
+					t.Errorf("unexpected error message %v", entry["error"])
+				}
+				if entry["error.code"] != float64(1001) {
+					t.Errorf("unexpected error code %v", entry["error.code"])
+				}
+				if fields, _ := entry["error.fields"].(map[string]interface{}); fields["user_id"] != float64(42) || fields["password"] != "******" {
+					t.Errorf("unexpected error fields %v", entry["error.fields"])
+				}
+				if stack, _ := entry["error.stack"].([]interface{}); len(stack) == 0 ||
+					!strings.Contains(stack[0].(string), "error_test.go:") {
+					t.Errorf("unexpected error stack %v", entry["error.stack"])
+				}
+				if chain, _ := entry["error.chain"].([]interface{}); len(chain) != 2 || chain[1] != "record not found" {
+					t.Errorf("unexpected error chain %v", entry["error.chain"])
+				}
+			}
+			if !strings.Contains(lines[1], `"request failed: load user: record not found"`) {
+				t.Errorf("expected message without stack, got %s", lines[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package xlog
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultExitTimeout 退出钩子默认的总超时时间
+const defaultExitTimeout = 5 * time.Second
+
+var exitHooks = struct {
+	mu      sync.Mutex
+	hooks   []func(ctx context.Context)
+	timeout time.Duration
+}{timeout: defaultExitTimeout}
+
+// RegisterExitHook registers a hook that runs before Fatal exits the process or when RunExitHooks is called,
+// hooks run in reverse registration order like deferred calls and should return when ctx is done.
+func RegisterExitHook(hook func(ctx context.Context)) {
+	if hook == nil {
+		return
+	}
+	exitHooks.mu.Lock()
+	exitHooks.hooks = append(exitHooks.hooks, hook)
+	exitHooks.mu.Unlock()
+}
+
+// SetExitTimeout sets the total time the exit hooks may take, the default is 5s.
+func SetExitTimeout(timeout time.Duration) {
+	exitHooks.mu.Lock()
+	exitHooks.timeout = timeout
+	exitHooks.mu.Unlock()
+}
+
+// RunExitHooks runs and removes the registered hooks, it returns when all hooks
+// are done or the exit timeout expires; a panicking hook does not stop the others.
+func RunExitHooks() {
+	exitHooks.mu.Lock()
+	hooks, timeout := exitHooks.hooks, exitHooks.timeout
+	exitHooks.hooks = nil
+	exitHooks.mu.Unlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := len(hooks) - 1; i >= 0; i-- {
+			if ctx.Err() != nil {
+				return
+			}
+			runExitHook(ctx, hooks[i])
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		L().Log(WarnLevel, "exit hooks timed out", Any("timeout", timeout.String()))
+	}
+}
+
+func runExitHook(ctx context.Context, hook func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			L().Log(ErrorLevel, "exit hook panicked", Any("panic", r))
+		}
+	}()
+	hook(ctx)
+}
@@ -0,0 +1,66 @@
+package xlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunExitHooks(t *testing.T) {
+	SetExitTimeout(100 * time.Millisecond)
+	defer SetExitTimeout(defaultExitTimeout)
+
+	var order []int
+	RegisterExitHook(func(ctx context.Context) {
+		order = append(order, 1)
+	})
+	RegisterExitHook(func(ctx context.Context) {
+		order = append(order, 2)
+		panic("close failed")
+	})
+	RegisterExitHook(func(ctx context.Context) {
+		order = append(order, 3)
+	})
+	RunExitHooks()
+	if want := []int{3, 2, 1}; !reflect.DeepEqual(order, want) {
+		t.Errorf("expected hooks to run in %v, got %v", want, order)
+	}
+
+	// 超时后不再等待未完成的钩子
+	RegisterExitHook(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+	})
+	start := time.Now()
+	RunExitHooks()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("exit hooks should time out, took %s", elapsed)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			l, err := New(Config{Backend: backend, Format: FormatJSON, Outputs: []io.Writer{&out}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			func() {
+				defer func() {
+					if r := recover(); r != "user 1 not found" {
+						t.Errorf("unexpected panic value %v", r)
+					}
+				}()
+				l.Panicf("user %d not found", 1)
+			}()
+			if !strings.Contains(strings.ToLower(out.String()), `"level":"panic"`) {
+				t.Errorf("expected panic level in %s", out.String())
+			}
+		})
+	}
+}
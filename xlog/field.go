@@ -0,0 +1,70 @@
+package xlog
+
+import (
+	"sort"
+)
+
+const (
+	// errorKey Err 与 error 参数展开后使用的字段名
+	errorKey = "error"
+	// loggerKey Named logger 的名称字段
+	loggerKey = "logger"
+)
+
+// Field 结构化日志字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Any constructs a field with the given key and value.
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err constructs a field that carries an error under the "error" key,
+// it is expanded into error, error.code, error.fields, error.stack and error.chain.
+func Err(err error) Field {
+	return Field{Key: errorKey, Value: err}
+}
+
+// lazyValue Lazy 字段的值，在日志确定输出时才求值
+type lazyValue func() interface{}
+
+// Lazy constructs a field whose value is computed by fn only if the entry is written,
+// for values that are expensive to build such as message dumps. Fields passed to With
+// are evaluated when With is called. A disabled Log call still allocates the variadic
+// fields slice, use Logfn or Enabled where that allocation matters.
+func Lazy(key string, fn func() interface{}) Field {
+	return Field{Key: key, Value: lazyValue(fn)}
+}
+
+// ResolveFields evaluates the Lazy fields, it is used by Logger implementations
+// outside xlog; fields is returned unchanged if it has none.
+func ResolveFields(fields []Field) []Field {
+	var resolved []Field
+	for i, f := range fields {
+		fn, ok := f.Value.(lazyValue)
+		if !ok {
+			if resolved != nil {
+				resolved = append(resolved, f)
+			}
+			continue
+		}
+		if resolved == nil {
+			resolved = append(make([]Field, 0, len(fields)), fields[:i]...)
+		}
+		resolved = append(resolved, Field{Key: f.Key, Value: fn()})
+	}
+	if resolved == nil {
+		return fields
+	}
+	return resolved
+}
+
+// sortFields 按 key 排序，用于从 map 转换而来的字段
+func sortFields(fields []Field) {
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+}
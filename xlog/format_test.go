@@ -0,0 +1,73 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+func TestLogfmt(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			l, err := New(Config{Backend: backend, Format: FormatLogfmt, Caller: true, Outputs: []io.Writer{&out}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Named("demo").Log(WarnLevel, "disk full", Any("path", "/data"), Any("note", `a "b"`), Err(gerror.New("boom")))
+
+			line := out.String()
+			if strings.Count(line, "\n") != 1 {
+				t.Fatalf("expected a single line, got %q", line)
+			}
+			for _, want := range []string{" level=warn ", ` msg="disk full" `, " file=format_test.go:", " logger=demo", " path=/data", ` note="a \"b\""`, " error=boom", ` error.stack="`} {
+				if !strings.Contains(line, want) {
+					t.Errorf("expected %q in %q", want, line)
+				}
+			}
+		})
+	}
+}
+
+func TestECS(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			l, err := New(Config{Backend: backend, Format: FormatECS, Caller: true, Outputs: []io.Writer{&out}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Named("demo").Log(ErrorLevel, "failed", Any("order", 42), Err(gerror.New("boom")))
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid json %q: %v", out.String(), err)
+			}
+			for key, want := range map[string]interface{}{
+				"log.level":            "error",
+				"message":              "failed",
+				"log.origin.file.name": "format_test.go",
+				"log.logger":           "demo",
+				"error.message":        "boom",
+				"order":                float64(42),
+			} {
+				if entry[key] != want {
+					t.Errorf("%s = %v, want %v", key, entry[key], want)
+				}
+			}
+			if _, ok := entry["@timestamp"].(string); !ok {
+				t.Errorf("missing @timestamp in %v", entry)
+			}
+			if line, _ := entry["log.origin.file.line"].(float64); line == 0 {
+				t.Errorf("missing log.origin.file.line in %v", entry)
+			}
+			if stack, _ := entry["error.stack_trace"].(string); !strings.Contains(stack, "format_test.go:") {
+				t.Errorf("unexpected error.stack_trace %q", stack)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package xlog
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap/zapcore"
+)
+
+// Hook 后端无关的日志钩子，用于告警、错误上报等插件，通过 Config.Hooks 注册：
+// logrus 后端注册为 logrus hook，zap 后端包装为 zapcore.Core，其余后端在写入后调用
+//
+// Fire 在写日志的 goroutine 中同步调用，耗时操作应自行异步处理；
+// Fire 返回的错误与 panic 输出到标准错误，不影响日志写入与其他 Hook
+type Hook interface {
+	// Levels 返回触发 Hook 的级别，为空时所有级别都触发
+	Levels() []Level
+	Fire(Entry) error
+}
+
+// fireHook 调用 Hook，将 panic 转换为错误
+func fireHook(h Hook, e Entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("xlog: hook %T panicked: %v", h, r)
+		}
+	}()
+	if err := h.Fire(e); err != nil {
+		return fmt.Errorf("xlog: hook %T failed: %w", h, err)
+	}
+	return nil
+}
+
+// fireHooks 调用 e.Level 对应的 Hook，错误输出到标准错误
+func fireHooks(hooks []Hook, e Entry) {
+	for _, h := range hooks {
+		if hookFires(h, e.Level) {
+			if err := fireHook(h, e); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+	}
+}
+
+func hookFires(h Hook, level Level) bool {
+	levels := h.Levels()
+	return len(levels) == 0 || slices.Contains(levels, level)
+}
+
+// logrusHook 将 Hook 适配为 logrus.Hook
+//
+// logrus 在某个 hook 返回错误后不再调用其余的 hook，因此所有 Hook 合并为一个，错误直接输出
+type logrusHook struct {
+	hooks []Hook
+}
+
+// LogrusHook adapts hooks to a single logrus.Hook, for loggers created with xlogrus or logrus directly.
+func LogrusHook(hooks ...Hook) logrus.Hook {
+	return logrusHook{hooks: hooks}
+}
+
+func (l logrusHook) Levels() []logrus.Level {
+	var levels []logrus.Level
+	for _, level := range logrus.AllLevels {
+		if slices.ContainsFunc(l.hooks, func(h Hook) bool { return hookFires(h, fromLogrusLevel(level)) }) {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+func (l logrusHook) Fire(e *logrus.Entry) error {
+	fields := make([]Field, 0, len(e.Data))
+	for k, v := range e.Data {
+		fields = append(fields, Field{Key: k, Value: v})
+	}
+	sortFields(fields)
+	entry := Entry{Time: e.Time, Level: fromLogrusLevel(e.Level), Message: e.Message, Fields: fields}
+	if e.HasCaller() {
+		entry.Caller = e.Caller
+	}
+	fireHooks(l.hooks, entry)
+	return nil
+}
+
+// zapHookCore 将 Hook 适配为只调用 Hook、不输出的 zapcore.Core，
+// level 为被包装的 core，它未开启的级别不调用 Hook
+type zapHookCore struct {
+	level zapcore.LevelEnabler
+	hooks []Hook
+	with  []Field
+}
+
+// ZapCore wraps core so that every entry written to it also fires hooks,
+// for loggers created with xzap or zap directly.
+func ZapCore(core zapcore.Core, hooks ...Hook) zapcore.Core {
+	if len(hooks) == 0 {
+		return core
+	}
+	return zapcore.NewTee(core, &zapHookCore{level: core, hooks: hooks})
+}
+
+func (c *zapHookCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level) && slices.ContainsFunc(c.hooks, func(h Hook) bool {
+		return hookFires(h, Level(level))
+	})
+}
+
+func (c *zapHookCore) With(zapFields []zapcore.Field) zapcore.Core {
+	with := slices.Clip(c.with)
+	for _, f := range zapFields {
+		with = appendZapField(with, f)
+	}
+	return &zapHookCore{level: c.level, hooks: c.hooks, with: with}
+}
+
+func (c *zapHookCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *zapHookCore) Write(ent zapcore.Entry, zapFields []zapcore.Field) error {
+	fields := slices.Clip(c.with)
+	for _, f := range zapFields {
+		fields = appendZapField(fields, f)
+	}
+	entry := Entry{Time: ent.Time, Level: Level(ent.Level), Message: ent.Message, Fields: fields}
+	if ent.Caller.Defined {
+		entry.Caller = entryFrame(ent.Caller.File, ent.Caller.Line, ent.Caller.Function)
+	}
+	fireHooks(c.hooks, entry)
+	return nil
+}
+
+func (c *zapHookCore) Sync() error {
+	return nil
+}
+
+// hookCore 在 slog 与 native 后端写入后调用 Hook
+type hookCore struct {
+	core
+	hooks []Hook
+	with  []Field
+}
+
+func (c *hookCore) Log(t time.Time, level Level, template, msg string, fields []Field, frame *runtime.Frame) {
+	c.core.Log(t, level, template, msg, fields, frame)
+	if slices.ContainsFunc(c.hooks, func(h Hook) bool { return hookFires(h, level) }) {
+		fireHooks(c.hooks, Entry{Time: t, Level: level, Message: msg, Fields: append(slices.Clip(c.with), fields...), Caller: frame})
+	}
+}
+
+func (c *hookCore) With(fields []Field) core {
+	return &hookCore{core: c.core.With(fields), hooks: c.hooks, with: append(slices.Clip(c.with), fields...)}
+}
@@ -0,0 +1,125 @@
+package xlog
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type recordingHook struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (*recordingHook) Levels() []Level {
+	return []Level{WarnLevel, ErrorLevel}
+}
+
+func (h *recordingHook) Fire(e Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, e)
+	return nil
+}
+
+type panickingHook struct{}
+
+func (panickingHook) Levels() []Level {
+	return nil
+}
+
+func (panickingHook) Fire(Entry) error {
+	panic("boom")
+}
+
+func TestHook(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			hook := &recordingHook{}
+			l, err := New(Config{
+				Backend: backend,
+				Format:  FormatJSON,
+				Caller:  true,
+				Outputs: []io.Writer{&out},
+				Hooks:   []Hook{panickingHook{}, hook},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l = l.Named("order").With(Any("request", "r-1"))
+			l.Info("ignored")
+			l.Log(ErrorLevel, "failed", Any("id", 7))
+
+			if strings.Count(out.String(), "\n") != 2 {
+				t.Fatalf("a panicking hook must not break logging, got:\n%s", out.String())
+			}
+			if len(hook.entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(hook.entries))
+			}
+			e := hook.entries[0]
+			fields := make(map[string]interface{})
+			for _, f := range e.Fields {
+				fields[f.Key] = f.Value
+			}
+			if e.Level != ErrorLevel || e.Message != "failed" || fields["logger"] != "order" || fields["request"] != "r-1" || fields["id"] == nil {
+				t.Errorf("unexpected entry %+v", e)
+			}
+			if e.Caller == nil || filepath.Base(e.Caller.File) != "hook_test.go" {
+				t.Errorf("unexpected caller %+v", e.Caller)
+			}
+		})
+	}
+}
+
+func TestHookFailingSink(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			hook := &recordingHook{}
+			l, err := New(Config{
+				Backend: backend,
+				Sinks:   []Sink{{Writer: failingWriter{}, Format: FormatJSON}, {Writer: &out, Format: FormatJSON}},
+				Hooks:   []Hook{hook},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Log(ErrorLevel, "failed")
+			// 一个 Sink 写入失败不影响 Hook 与其他 Sink
+			if len(hook.entries) != 1 {
+				t.Errorf("got %d entries, want 1", len(hook.entries))
+			}
+			if !strings.Contains(out.String(), "failed") {
+				t.Errorf("other sink got %q", out.String())
+			}
+		})
+	}
+}
+
+// allLevelsHook 所有级别都触发
+type allLevelsHook struct {
+	recordingHook
+}
+
+func (*allLevelsHook) Levels() []Level {
+	return nil
+}
+
+func TestZapCoreLevel(t *testing.T) {
+	hook := &allLevelsHook{}
+	base := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(io.Discard), zapcore.InfoLevel)
+	l := zap.New(ZapCore(base, hook)).With(zap.String("app", "xgo"))
+	l.Debug("dropped by the core")
+	l.Info("written")
+
+	if len(hook.entries) != 1 || hook.entries[0].Message != "written" {
+		t.Fatalf("unexpected hook entries %+v", hook.entries)
+	}
+}
@@ -0,0 +1,148 @@
+// Package sample implements log sampling and repeated-message suppression
+// shared by the xlog backends.
+package sample
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RepeatedKey 合并重复日志时记录重复次数的字段名
+const RepeatedKey = "repeated"
+
+// TemplateKey zap 后端中携带消息模板的 Skip 字段名
+const TemplateKey = "xlog.template"
+
+type templateKey struct{}
+
+// WithTemplate 将 printf 风格调用的消息模板存入 ctx
+func WithTemplate(ctx context.Context, template string) context.Context {
+	return context.WithValue(ctx, templateKey{}, template)
+}
+
+// Template 返回 ctx 中的消息模板
+func Template(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	template, ok := ctx.Value(templateKey{}).(string)
+	return template, ok
+}
+
+// maxCounters 计数器数量超过该值时清理过期的计数器
+const maxCounters = 4096
+
+// Sampler 每个 tick 内同一 key 先放行 first 条，之后每 thereafter 条放行一条
+type Sampler struct {
+	tick       time.Duration
+	first      uint64
+	thereafter uint64
+
+	mu       sync.Mutex
+	counters map[string]*counter
+}
+
+type counter struct {
+	resetAt time.Time
+	n       uint64
+}
+
+// NewSampler 创建 Sampler，tick 为 0 时按 1 秒计算，thereafter 为 0 时超出 first 后全部丢弃
+func NewSampler(tick time.Duration, first, thereafter int) *Sampler {
+	if tick <= 0 {
+		tick = time.Second
+	}
+	return &Sampler{
+		tick:       tick,
+		first:      uint64(max(first, 0)),
+		thereafter: uint64(max(thereafter, 0)),
+		counters:   make(map[string]*counter),
+	}
+}
+
+// Sample 返回 true 表示该条日志应当输出
+func (s *Sampler) Sample(key string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.resetAt) {
+		if !ok && len(s.counters) >= maxCounters {
+			s.purge(now)
+		}
+		c = &counter{resetAt: now.Add(s.tick)}
+		s.counters[key] = c
+	}
+	c.n++
+	if c.n <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (c.n-s.first)%s.thereafter == 0
+}
+
+func (s *Sampler) purge(now time.Time) {
+	for key, c := range s.counters {
+		if !now.Before(c.resetAt) {
+			delete(s.counters, key)
+		}
+	}
+}
+
+// Dedup 合并连续重复的日志：重复的日志被抑制并计数，
+// 在出现不同的日志、window 到期或调用 Flush 时通过 emit 输出一条带重复次数的汇总
+type Dedup[T any] struct {
+	window time.Duration
+	emit   func(item T, repeated int)
+
+	mu       sync.Mutex
+	key      string
+	last     T
+	repeated int
+	timer    *time.Timer
+}
+
+// NewDedup 创建 Dedup，window 为 0 时按 1 秒计算
+func NewDedup[T any](window time.Duration, emit func(item T, repeated int)) *Dedup[T] {
+	if window <= 0 {
+		window = time.Second
+	}
+	return &Dedup[T]{window: window, emit: emit}
+}
+
+// Check 返回 true 表示 item 应当正常输出
+func (d *Dedup[T]) Check(key string, item T) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if key == d.key {
+		d.last = item
+		d.repeated++
+		if d.timer == nil {
+			d.timer = time.AfterFunc(d.window, d.Flush)
+		}
+		return false
+	}
+	d.flush()
+	d.key = key
+	d.last = item
+	return true
+}
+
+// Flush 立即输出待汇总的重复日志
+func (d *Dedup[T]) Flush() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.flush()
+}
+
+func (d *Dedup[T]) flush() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.repeated > 0 {
+		d.emit(d.last, d.repeated)
+		d.repeated = 0
+	}
+}
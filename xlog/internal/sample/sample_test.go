@@ -0,0 +1,80 @@
+package sample
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	s := NewSampler(time.Second, 2, 3)
+	now := time.Now()
+
+	var kept []int
+	for i := 1; i <= 10; i++ {
+		if s.Sample("a", now) {
+			kept = append(kept, i)
+		}
+	}
+	want := []int{1, 2, 5, 8}
+	if len(kept) != len(want) {
+		t.Fatalf("kept %v, want %v", kept, want)
+	}
+	for i := range want {
+		if kept[i] != want[i] {
+			t.Fatalf("kept %v, want %v", kept, want)
+		}
+	}
+
+	if !s.Sample("b", now) {
+		t.Error("keys must be counted separately")
+	}
+	if !s.Sample("a", now.Add(time.Second)) {
+		t.Error("counter must reset after tick")
+	}
+}
+
+func TestDedup(t *testing.T) {
+	type summary struct {
+		item     string
+		repeated int
+	}
+	var emitted []summary
+	d := NewDedup(time.Hour, func(item string, repeated int) {
+		emitted = append(emitted, summary{item, repeated})
+	})
+
+	results := []bool{
+		d.Check("a", "a1"),
+		d.Check("a", "a2"),
+		d.Check("a", "a3"),
+		d.Check("b", "b1"),
+		d.Check("b", "b2"),
+	}
+	want := []bool{true, false, false, true, false}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Fatalf("check results %v, want %v", results, want)
+		}
+	}
+	d.Flush()
+	if len(emitted) != 2 || emitted[0] != (summary{"a3", 2}) || emitted[1] != (summary{"b2", 1}) {
+		t.Fatalf("unexpected summaries %v", emitted)
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	done := make(chan int, 1)
+	d := NewDedup(10*time.Millisecond, func(_ string, repeated int) {
+		done <- repeated
+	})
+	d.Check("a", "a")
+	d.Check("a", "a")
+	select {
+	case repeated := <-done:
+		if repeated != 1 {
+			t.Fatalf("expected 1 repeat, got %d", repeated)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("window did not flush")
+	}
+}
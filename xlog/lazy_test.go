@@ -0,0 +1,53 @@
+package xlog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			l, err := New(Config{Backend: backend, Level: InfoLevel, Format: FormatJSON, Outputs: []io.Writer{&out}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			calls := 0
+			dump := func() interface{} {
+				calls++
+				return map[string]int{"offset": 42}
+			}
+			msg := func() string {
+				calls++
+				return "lazy message"
+			}
+
+			fields := func() []Field {
+				calls++
+				return []Field{Any("topic", "orders")}
+			}
+
+			l.Log(DebugLevel, "disabled", Lazy("message", dump))
+			l.Debugfn(msg)
+			l.Logfn(DebugLevel, "disabled", fields)
+			if calls != 0 || out.Len() != 0 {
+				t.Fatalf("disabled entries must not evaluate arguments, %d calls, output %q", calls, out.String())
+			}
+
+			l.Log(InfoLevel, "enabled", Lazy("message", dump))
+			l.Warnfn(msg)
+			l.Logfn(InfoLevel, "enabled", fields)
+			if calls != 3 {
+				t.Fatalf("got %d calls, want 3", calls)
+			}
+			for _, want := range []string{`"message":{"offset":42}`, `"msg":"lazy message"`, `"topic":"orders"`} {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected %s in %s", want, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package xlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// levelRequest PUT 请求体，logger 与 package 均为空时修改默认级别
+//
+//	{"level": "debug", "package": "xmq/xkafka", "ttl": "10m"}
+type levelRequest struct {
+	Level   *Level `json:"level"`
+	Logger  string `json:"logger,omitempty"`
+	Package string `json:"package,omitempty"`
+	// TTL 到期后自动恢复修改前的级别，为空表示永久生效
+	TTL string `json:"ttl,omitempty"`
+}
+
+type levelResponse struct {
+	Level    Level            `json:"level"`
+	Loggers  map[string]Level `json:"loggers,omitempty"`
+	Packages map[string]Level `json:"packages,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP is a simple JSON endpoint that can report on or change the levels.
+//
+// GET 返回当前默认级别与所有覆盖；PUT 修改默认级别或某个 logger/包的级别，
+// 可通过 ttl 自动恢复；DELETE 通过 ?logger= 或 ?package= 删除覆盖
+func (a *AtomicLevel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		err = a.serveUpdate(r)
+	case http.MethodDelete:
+		err = a.serveDelete(r)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "only GET, PUT and DELETE are supported"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, levelResponse{
+		Level:    a.Level(),
+		Loggers:  a.LoggerLevels(),
+		Packages: a.PackageLevels(),
+	})
+}
+
+func (a *AtomicLevel) serveUpdate(r *http.Request) error {
+	var req levelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return fmt.Errorf("request body must be valid JSON: %w", err)
+	}
+	if req.Level == nil {
+		return fmt.Errorf("must specify a logging level")
+	}
+	// 与 SetPackageLevel 相同去掉首尾的 /，使还原时查找与定时器使用同一个 key
+	req.Package = strings.Trim(req.Package, "/")
+	if req.Logger != "" && req.Package != "" {
+		return fmt.Errorf("logger and package cannot be set at the same time")
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil {
+			return fmt.Errorf("invalid ttl: %w", err)
+		}
+		if ttl < 0 {
+			return fmt.Errorf("ttl cannot be negative")
+		}
+	}
+
+	level := *req.Level
+	switch {
+	case req.Logger != "":
+		prev, ok := a.LoggerLevels()[req.Logger]
+		a.SetLoggerLevel(req.Logger, level)
+		a.revertAfter("logger:"+req.Logger, ttl, func() {
+			if ok {
+				a.SetLoggerLevel(req.Logger, prev)
+			} else {
+				a.UnsetLoggerLevel(req.Logger)
+			}
+		})
+	case req.Package != "":
+		prev, ok := a.PackageLevels()[req.Package]
+		a.SetPackageLevel(req.Package, level)
+		a.revertAfter("package:"+req.Package, ttl, func() {
+			if ok {
+				a.SetPackageLevel(req.Package, prev)
+			} else {
+				a.UnsetPackageLevel(req.Package)
+			}
+		})
+	default:
+		prev := a.Level()
+		a.SetLevel(level)
+		a.revertAfter("level", ttl, func() {
+			a.SetLevel(prev)
+		})
+	}
+	return nil
+}
+
+func (a *AtomicLevel) serveDelete(r *http.Request) error {
+	query := r.URL.Query()
+	switch {
+	case query.Get("logger") != "":
+		name := query.Get("logger")
+		a.revertAfter("logger:"+name, 0, nil)
+		a.UnsetLoggerLevel(name)
+	case query.Get("package") != "":
+		pkg := strings.Trim(query.Get("package"), "/")
+		a.revertAfter("package:"+pkg, 0, nil)
+		a.UnsetPackageLevel(pkg)
+	default:
+		return fmt.Errorf("must specify logger or package")
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// LevelHandler returns an http.Handler that reports on or changes the levels
+// of the current global logger, see AtomicLevel.ServeHTTP.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a := globalLevel()
+		if a == nil {
+			writeJSON(w, http.StatusNotImplemented, errorResponse{Error: "global logger does not support dynamic levels"})
+			return
+		}
+		a.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,118 @@
+package xlog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rabbit-rm/xgo/internal/buffer"
+	"github.com/rabbit-rm/xgo/internal/caller"
+	"github.com/rabbit-rm/xgo/xlog/internal/sample"
+)
+
+// logfmtTimeLayout logfmt 通常由程序解析，时间带毫秒与时区
+const logfmtTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// logfmtEncoder 输出单行的 logfmt：字段名与 text 格式一致，值只在需要时加引号，
+// error.stack 合并为一个带换行转义的字符串，嵌套结构按紧凑的 JSON 输出
+type logfmtEncoder struct{}
+
+func (enc logfmtEncoder) encode(buf *buffer.Buffer, e nativeEntry) {
+	buf.AppendString(nativeTimeKey + "=")
+	buf.AppendTime(e.time, logfmtTimeLayout)
+	buf.AppendString(" " + nativeLevelKey + "=")
+	buf.AppendString(e.level.String())
+	buf.AppendString(" " + nativeMsgKey + "=")
+	appendLogfmtString(buf, e.msg)
+	if e.frame != nil {
+		buf.AppendString(" " + nativeCallerKey + "=")
+		if file := caller.RelFile(e.frame.File); needsLogfmtQuote(file) {
+			appendQuotedCaller(buf, e.frame)
+		} else {
+			buf.AppendString(file)
+			buf.AppendByte(':')
+			buf.AppendInt(int64(e.frame.Line))
+		}
+	}
+	buf.AppendBytes(e.context)
+	enc.appendFields(buf, e.fields)
+	if e.repeated > 0 {
+		buf.AppendString(" " + sample.RepeatedKey + "=")
+		buf.AppendInt(int64(e.repeated))
+	}
+	buf.AppendByte('\n')
+}
+
+func (logfmtEncoder) appendFields(buf *buffer.Buffer, fields []Field) {
+	for _, f := range fields {
+		buf.AppendByte(' ')
+		appendLogfmtKey(buf, fieldKey(f.Key))
+		buf.AppendByte('=')
+		if strings.HasSuffix(f.Key, ".stack") {
+			if lines := stringLines(f.Value); lines != nil {
+				appendLogfmtString(buf, strings.Join(lines, "\n"))
+				continue
+			}
+		}
+		appendLogfmtValue(buf, f.Value)
+	}
+}
+
+func appendLogfmtValue(buf *buffer.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		buf.AppendString("null")
+	case string:
+		appendLogfmtString(buf, v)
+	case time.Time:
+		buf.AppendTime(v, time.RFC3339Nano)
+	case time.Duration:
+		buf.AppendString(v.String())
+	case error:
+		appendLogfmtString(buf, v.Error())
+	case fmt.Stringer:
+		appendLogfmtString(buf, v.String())
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		appendTextValue(buf, v)
+	default:
+		if data, ok := consoleJSON(v); ok {
+			appendLogfmtString(buf, string(data))
+			return
+		}
+		appendLogfmtString(buf, fmt.Sprint(v))
+	}
+}
+
+// appendLogfmtKey 字段名中的空白、引号与等号替换为 _
+func appendLogfmtKey(buf *buffer.Buffer, key string) {
+	if key == "" {
+		buf.AppendByte('_')
+		return
+	}
+	if !needsLogfmtQuote(key) {
+		buf.AppendString(key)
+		return
+	}
+	buf.AppendString(strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '"' || r == '=' || r == 0x7f || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, key))
+}
+
+// appendLogfmtString 只在为空或包含空白、引号、等号与控制字符时加引号
+func appendLogfmtString(buf *buffer.Buffer, s string) {
+	if s != "" && !needsLogfmtQuote(s) {
+		buf.AppendString(s)
+		return
+	}
+	appendJSONString(buf, s)
+}
+
+func needsLogfmtQuote(s string) bool {
+	return strings.ContainsFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '"' || r == '=' || r == '\\' || r == 0x7f || r == utf8.RuneError
+	})
+}
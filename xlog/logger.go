@@ -1,52 +1,159 @@
 package xlog
 
-var logger Logger
+import (
+	"sync/atomic"
 
+	"github.com/rabbit-rm/xgo/internal/caller"
+)
+
+// holder 包装 Logger，使不同实现可以存入同一个 atomic.Pointer
+type holder struct {
+	Logger
+}
+
+var global atomic.Pointer[holder]
+
+func init() {
+	logger, err := New(DefaultConfig())
+	if err != nil {
+		panic(err)
+	}
+	MustSetLogger(logger)
+}
+
+// MustSetLogger atomically replaces the global logger, it is safe to call
+// while other goroutines are logging.
 func MustSetLogger(l Logger) {
 	if l == nil {
 		panic("logger cannot be nil")
 	}
-	logger = l
+	global.Store(&holder{Logger: l})
+}
+
+// L returns the current global logger.
+func L() Logger {
+	return global.Load().Logger
 }
 
 func Debug(args ...interface{}) {
-	logger.Debug(args...)
+	L().Debug(args...)
 }
 
 func Info(args ...interface{}) {
-	logger.Info(args...)
+	L().Info(args...)
 }
 
 func Warn(args ...interface{}) {
-	logger.Warn(args...)
+	L().Warn(args...)
 }
 
 func Error(args ...interface{}) {
-	logger.Error(args...)
+	L().Error(args...)
+}
+
+func Panic(args ...interface{}) {
+	L().Panic(args...)
 }
 
 func Fatal(args ...interface{}) {
-	logger.Fatal(args...)
+	L().Fatal(args...)
 }
 
 func Debugf(format string, args ...interface{}) {
-	logger.Debugf(format, args...)
+	L().Debugf(format, args...)
 }
 
 func Infof(format string, args ...interface{}) {
-	logger.Infof(format, args...)
+	L().Infof(format, args...)
 }
 
 func Warnf(format string, args ...interface{}) {
-	logger.Warnf(format, args...)
+	L().Warnf(format, args...)
 }
 
 func Errorf(format string, args ...interface{}) {
-	logger.Errorf(format, args...)
+	L().Errorf(format, args...)
+}
+
+func Panicf(format string, args ...interface{}) {
+	L().Panicf(format, args...)
 }
 
 func Fatalf(format string, args ...interface{}) {
-	logger.Fatalf(format, args...)
+	L().Fatalf(format, args...)
+}
+
+// Debugfn logs the message returned by fn at DebugLevel, fn is called only if DebugLevel is enabled.
+func Debugfn(fn func() string) {
+	L().Debugfn(fn)
+}
+
+// Infofn logs the message returned by fn at InfoLevel, fn is called only if InfoLevel is enabled.
+func Infofn(fn func() string) {
+	L().Infofn(fn)
+}
+
+// Warnfn logs the message returned by fn at WarnLevel, fn is called only if WarnLevel is enabled.
+func Warnfn(fn func() string) {
+	L().Warnfn(fn)
+}
+
+// Errorfn logs the message returned by fn at ErrorLevel, fn is called only if ErrorLevel is enabled.
+func Errorfn(fn func() string) {
+	L().Errorfn(fn)
+}
+
+// Enabled reports whether the global logger writes entries at level, use it to guard
+// expensive logging code:
+//
+//	if xlog.Enabled(xlog.DebugLevel) {
+//		xlog.Debugf("message: %s", dump(msg))
+//	}
+func Enabled(level Level) bool {
+	return L().Enabled(level)
+}
+
+// Log logs a message with structured fields at the given level.
+func Log(level Level, msg string, fields ...Field) {
+	L().Log(level, msg, fields...)
+}
+
+// Logfn logs msg with the fields returned by fn, fn is called only if level is enabled.
+func Logfn(level Level, msg string, fn func() []Field) {
+	L().Logfn(level, msg, fn)
+}
+
+// With returns a child of the global logger carrying the given fields.
+func With(fields ...Field) Logger {
+	return L().With(fields...)
+}
+
+// Named returns a child of the current global logger named name, see Logger.Named.
+// Libraries should call it when they are constructed rather than at package initialization,
+// so that the logger installed by Init is used.
+func Named(name string) Logger {
+	return L().Named(name)
+}
+
+// WithCallerSkip returns a child of the global logger that reports the caller skip frames further up the stack.
+func WithCallerSkip(skip int) Logger {
+	return L().WithCallerSkip(skip)
+}
+
+// Helper marks the calling function as a logging helper, like testing.T.Helper;
+// the caller reported for entries and xerror callers skips it.
+func Helper() {
+	caller.MarkHelper(1)
+}
+
+// Sync flushes buffered entries of the global logger.
+func Sync() error {
+	return L().Sync()
+}
+
+// Close flushes and releases the global logger, call it before the process exits.
+func Close() error {
+	return L().Close()
 }
 
 type Logger interface {
@@ -54,10 +161,38 @@ type Logger interface {
 	Info(args ...interface{})
 	Warn(args ...interface{})
 	Error(args ...interface{})
+	// Panic logs at PanicLevel and then panics with the message, even if PanicLevel is disabled
+	Panic(args ...interface{})
+	// Fatal logs at FatalLevel, runs the exit hooks, flushes the logger and exits the process
 	Fatal(args ...interface{})
 	Debugf(format string, args ...interface{})
 	Infof(format string, args ...interface{})
 	Warnf(format string, args ...interface{})
 	Errorf(format string, args ...interface{})
+	Panicf(format string, args ...interface{})
 	Fatalf(format string, args ...interface{})
+	// Debugfn, Infofn, Warnfn and Errorfn call fn to build the message only if the level is enabled
+	Debugfn(fn func() string)
+	Infofn(fn func() string)
+	Warnfn(fn func() string)
+	Errorfn(fn func() string)
+
+	// Log logs msg with structured fields, PanicLevel and FatalLevel entries panic and exit like Panic and Fatal
+	Log(level Level, msg string, fields ...Field)
+	// Logfn is like Log but calls fn to build the fields only if level is enabled,
+	// a disabled call does not allocate when fn captures no variables
+	Logfn(level Level, msg string, fn func() []Field)
+	// With returns a child logger that adds fields to every entry
+	With(fields ...Field) Logger
+	// Named returns a child logger whose entries carry a "logger" field, nested names are joined by ".";
+	// the child inherits the configuration and its level can be set with SetLoggerLevel
+	Named(name string) Logger
+	// WithCallerSkip returns a child logger that reports the caller skip frames further up the stack
+	WithCallerSkip(skip int) Logger
+	// Enabled reports whether entries at level would be written
+	Enabled(level Level) bool
+	// Sync flushes buffered entries to the outputs
+	Sync() error
+	// Close flushes buffered entries and closes the outputs, the logger and its children must not be used afterwards
+	Close() error
 }
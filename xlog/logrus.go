@@ -1,56 +1,206 @@
-//go:build !zap
-
 package xlog
 
 import (
+	"bytes"
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/rabbit-rm/xgo/internal/caller"
+	"github.com/rabbit-rm/xgo/xlog/internal/sample"
 	"github.com/rabbit-rm/xgo/xlog/xlogrus"
 	"github.com/sirupsen/logrus"
 )
 
-func init() {
-	MustSetLogger(&logrusLogger{l: xlogrus.NewLogger()})
+func newLogrusCore(config Config, sinks []Sink) core {
+	// 级别由 AtomicLevel 统一控制，后端只按 Sink 的级别过滤
+	var opts []xlogrus.Option
+	if len(sinks) == 1 && sinks[0].isPlain() {
+		opts = append(opts,
+			xlogrus.WithLevel(toLogrusLevel(sinks[0].Level)),
+			xlogrus.WithOut(sinks[0].Writer),
+			xlogrus.WithFormatter(logrusFormatter(sinks[0])),
+		)
+	} else {
+		logrusSinks := make([]xlogrus.Sink, 0, len(sinks))
+		for _, sink := range sinks {
+			logrusSinks = append(logrusSinks, xlogrus.Sink{
+				Out:        sink.Writer,
+				WriteLevel: logrusWriteLevel(sink.levelWriter()),
+				Level:      toLogrusLevel(sink.Level),
+				Formatter:  logrusFormatter(sink),
+				Filter:     logrusFilter(sink.Filter),
+				Sampling:   logrusSampling(sink.Sampling),
+				Dedup:      logrusDedup(sink.Dedup),
+			})
+		}
+		opts = append(opts, xlogrus.WithLevel(logrus.DebugLevel), xlogrus.WithSinks(logrusSinks...))
+	}
+	if !config.Caller {
+		opts = append(opts, xlogrus.DisableReportCaller())
+	}
+	if len(config.Hooks) > 0 {
+		opts = append(opts, xlogrus.WithHooks(LogrusHook(config.Hooks...)))
+	}
+	return &logrusCore{l: logrus.NewEntry(xlogrus.NewLogger(opts...))}
 }
 
-type logrusLogger struct {
-	l *logrus.Logger
+type logrusCore struct {
+	l *logrus.Entry
 }
 
-func (logger *logrusLogger) Debug(args ...interface{}) {
-	logger.l.Debug(args...)
+func (c *logrusCore) Log(t time.Time, level Level, template, msg string, fields []Field, frame *runtime.Frame) {
+	entry := c.l.WithTime(t)
+	if len(fields) > 0 {
+		entry = entry.WithFields(logrusFields(fields))
+	}
+	if template != "" || frame != nil {
+		ctx := context.Background()
+		if template != "" {
+			ctx = sample.WithTemplate(ctx, template)
+		}
+		if frame != nil {
+			ctx = caller.WithFrame(ctx, *frame)
+		}
+		entry = entry.WithContext(ctx)
+	}
+	if level == PanicLevel {
+		// Entry.Log 在 PanicLevel 下写入后会 panic，panic 由 logger 处理
+		defer func() { _ = recover() }()
+	}
+	// Entry.Log 在 FatalLevel 下不会退出进程，退出由 logger 处理
+	entry.Log(toLogrusLevel(level), msg)
 }
 
-func (logger *logrusLogger) Info(args ...interface{}) {
-	logger.l.Info(args...)
+func (c *logrusCore) With(fields []Field) core {
+	return &logrusCore{l: c.l.WithFields(logrusFields(fields))}
 }
 
-func (logger *logrusLogger) Warn(args ...interface{}) {
-	logger.l.Warn(args...)
+func (c *logrusCore) Sync() error {
+	return xlogrus.Sync(c.l.Logger)
+}
+
+func logrusFormatter(sink Sink) logrus.Formatter {
+	switch sink.Format {
+	case FormatJSON:
+		return xlogrus.NewJSONFormatter()
+	case FormatConsole, FormatLogfmt, FormatECS:
+		return logrusEncoderFormatter{enc: newEncoder(sink)}
+	default:
+		return xlogrus.NewTextFormatter()
+	}
+}
+
+// logrusEncoderFormatter 以 xlog 编码器实现的格式输出 logrus 日志
+type logrusEncoderFormatter struct {
+	enc encoder
+}
+
+func (f logrusEncoderFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	fields := make([]Field, 0, len(e.Data))
+	for k, v := range e.Data {
+		fields = append(fields, Field{Key: k, Value: v})
+	}
+	sortFields(fields)
+	entry := nativeEntry{time: e.Time, level: fromLogrusLevel(e.Level), msg: e.Message, fields: fields}
+	if e.HasCaller() {
+		entry.frame = e.Caller
+	}
+
+	buf := nativeBufferPool.Get()
+	defer buf.Free()
+	f.enc.encode(buf, entry)
+	out := e.Buffer
+	if out == nil {
+		out = &bytes.Buffer{}
+	}
+	out.Write(buf.Bytes())
+	return out.Bytes(), nil
 }
 
-func (logger *logrusLogger) Error(args ...interface{}) {
-	logger.l.Error(args...)
+func logrusFilter(filter func(Entry) bool) func(*logrus.Entry) bool {
+	if filter == nil {
+		return nil
+	}
+	return func(e *logrus.Entry) bool {
+		fields := make([]Field, 0, len(e.Data))
+		for k, v := range e.Data {
+			fields = append(fields, Field{Key: k, Value: v})
+		}
+		sortFields(fields)
+		return filter(Entry{Time: e.Time, Level: fromLogrusLevel(e.Level), Message: e.Message, Fields: fields})
+	}
 }
 
-func (logger *logrusLogger) Fatal(args ...interface{}) {
-	logger.l.Fatal(args...)
+func logrusWriteLevel(write func(Level, []byte) (int, error)) func(logrus.Level, []byte) (int, error) {
+	if write == nil {
+		return nil
+	}
+	return func(level logrus.Level, p []byte) (int, error) {
+		return write(fromLogrusLevel(level), p)
+	}
 }
 
-func (logger *logrusLogger) Debugf(format string, args ...interface{}) {
-	logger.l.Debugf(format, args...)
+func logrusSampling(sampling *Sampling) *xlogrus.Sampling {
+	if sampling == nil {
+		return nil
+	}
+	return &xlogrus.Sampling{
+		Tick:       sampling.Tick,
+		First:      sampling.First,
+		Thereafter: sampling.Thereafter,
+		Levels:     convertLevels(sampling.Levels, toLogrusLevel),
+	}
 }
 
-func (logger *logrusLogger) Infof(format string, args ...interface{}) {
-	logger.l.Infof(format, args...)
+func logrusDedup(dedup *Dedup) *xlogrus.Dedup {
+	if dedup == nil {
+		return nil
+	}
+	return &xlogrus.Dedup{
+		Window: dedup.Window,
+		Levels: convertLevels(dedup.Levels, toLogrusLevel),
+	}
 }
 
-func (logger *logrusLogger) Warnf(format string, args ...interface{}) {
-	logger.l.Warnf(format, args...)
+func logrusFields(fields []Field) logrus.Fields {
+	data := make(logrus.Fields, len(fields))
+	for _, f := range fields {
+		data[f.Key] = f.Value
+	}
+	return data
 }
 
-func (logger *logrusLogger) Errorf(format string, args ...interface{}) {
-	logger.l.Errorf(format, args...)
+func toLogrusLevel(level Level) logrus.Level {
+	switch level {
+	case DebugLevel:
+		return logrus.DebugLevel
+	case InfoLevel:
+		return logrus.InfoLevel
+	case WarnLevel:
+		return logrus.WarnLevel
+	case ErrorLevel:
+		return logrus.ErrorLevel
+	case PanicLevel:
+		return logrus.PanicLevel
+	default:
+		return logrus.FatalLevel
+	}
 }
 
-func (logger *logrusLogger) Fatalf(format string, args ...interface{}) {
-	logger.l.Fatalf(format, args...)
+func fromLogrusLevel(level logrus.Level) Level {
+	switch level {
+	case logrus.PanicLevel:
+		return PanicLevel
+	case logrus.FatalLevel:
+		return FatalLevel
+	case logrus.ErrorLevel:
+		return ErrorLevel
+	case logrus.WarnLevel:
+		return WarnLevel
+	case logrus.InfoLevel:
+		return InfoLevel
+	default:
+		return DebugLevel
+	}
 }
@@ -0,0 +1,246 @@
+package xlog
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// levelCount 按级别计数的槽位数，DebugLevel 到 FatalLevel
+const levelCount = int(FatalLevel-DebugLevel) + 1
+
+// Metrics 统计日志条数与输出情况，可在多个 Logger 间共享：
+// 条数按级别与 logger 名称统计，输出按 Sink 统计写入字节数、写入错误与丢弃条数
+type Metrics struct {
+	mu      sync.Mutex
+	entries map[string]*entryCounters
+	sinks   map[string]*sinkCounters
+	// droppers 各 Sink 中提供 Dropped 的 Writer，快照时读取
+	droppers map[string][]interface{ Dropped() uint64 }
+}
+
+type entryCounters [levelCount]atomic.Uint64
+
+func (c *entryCounters) inc(level Level) {
+	if i := int(level - DebugLevel); i >= 0 && i < levelCount {
+		c[i].Add(1)
+	}
+}
+
+type sinkCounters struct {
+	bytes  atomic.Uint64
+	errors atomic.Uint64
+}
+
+// NewMetrics creates an empty Metrics, pass it to Config.Metrics to collect statistics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		entries:  make(map[string]*entryCounters),
+		sinks:    make(map[string]*sinkCounters),
+		droppers: make(map[string][]interface{ Dropped() uint64 }),
+	}
+}
+
+// logger 返回 logger 名称对应的计数器，Metrics 为 nil 时返回 nil
+func (m *Metrics) logger(name string) *entryCounters {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.entries[name]
+	if !ok {
+		c = &entryCounters{}
+		m.entries[name] = c
+	}
+	return c
+}
+
+// wrap 包装 Sink 的 Writer 以统计写入字节数与错误
+func (m *Metrics) wrap(name string, w io.Writer) io.Writer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.sinks[name]
+	if !ok {
+		c = &sinkCounters{}
+		m.sinks[name] = c
+	}
+	mw := meteredWriter{w: w, counters: c}
+	if lw, ok := w.(LevelWriter); ok {
+		return meteredLevelWriter{meteredWriter: mw, lw: lw}
+	}
+	return mw
+}
+
+// watchDropped 逐层 Unwrap Sink 的 Writer，记录其中可统计丢弃条数的 Writer
+func (m *Metrics) watchDropped(name string, w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for w != nil {
+		d, ok := w.(interface{ Dropped() uint64 })
+		// 多个 Logger 共享 Metrics 与 Writer 时只记录一次
+		if ok && !(reflect.TypeOf(d).Comparable() && slices.Contains(m.droppers[name], d)) {
+			m.droppers[name] = append(m.droppers[name], d)
+		}
+		u, ok := w.(interface{ Unwrap() io.Writer })
+		if !ok {
+			return
+		}
+		w = u.Unwrap()
+	}
+}
+
+// EntryCount 某个 logger 在某个级别的日志条数，Logger 为空表示未命名的 logger
+type EntryCount struct {
+	Logger string `json:"logger"`
+	Level  Level  `json:"level"`
+	Count  uint64 `json:"count"`
+}
+
+// SinkStats 某个 Sink 的输出统计
+type SinkStats struct {
+	Name string `json:"name"`
+	// Bytes 成功写入的字节数，Errors 写入失败的次数
+	Bytes  uint64 `json:"bytes"`
+	Errors uint64 `json:"errors"`
+	// Dropped Writer 因缓冲区已满或发送失败丢弃的条数
+	Dropped uint64 `json:"dropped"`
+}
+
+// MetricsSnapshot Metrics 在某一时刻的统计，按名称排序，不包括条数为 0 的级别
+type MetricsSnapshot struct {
+	Entries []EntryCount `json:"entries"`
+	Sinks   []SinkStats  `json:"sinks"`
+}
+
+// Snapshot returns the current counters.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var s MetricsSnapshot
+	for _, name := range slices.Sorted(maps.Keys(m.entries)) {
+		c := m.entries[name]
+		for i := range c {
+			if n := c[i].Load(); n > 0 {
+				s.Entries = append(s.Entries, EntryCount{Logger: name, Level: DebugLevel + Level(i), Count: n})
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.sinks)) {
+		c := m.sinks[name]
+		stats := SinkStats{Name: name, Bytes: c.bytes.Load(), Errors: c.errors.Load()}
+		for _, d := range m.droppers[name] {
+			stats.Dropped += d.Dropped()
+		}
+		s.Sinks = append(s.Sinks, stats)
+	}
+	return s
+}
+
+// WritePrometheus writes the counters in the Prometheus text exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	s := m.Snapshot()
+	var b strings.Builder
+	b.WriteString("# HELP xlog_entries_total Number of log entries by level and logger.\n")
+	b.WriteString("# TYPE xlog_entries_total counter\n")
+	for _, e := range s.Entries {
+		fmt.Fprintf(&b, "xlog_entries_total{level=%s,logger=%s} %d\n", promLabel(e.Level.String()), promLabel(e.Logger), e.Count)
+	}
+	for _, metric := range []struct {
+		name, help string
+		value      func(SinkStats) uint64
+	}{
+		{"xlog_sink_bytes_total", "Bytes written by sink.", func(s SinkStats) uint64 { return s.Bytes }},
+		{"xlog_sink_write_errors_total", "Failed writes by sink.", func(s SinkStats) uint64 { return s.Errors }},
+		{"xlog_sink_dropped_total", "Entries dropped by sink.", func(s SinkStats) uint64 { return s.Dropped }},
+	} {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", metric.name, metric.help, metric.name)
+		for _, sink := range s.Sinks {
+			fmt.Fprintf(&b, "%s{sink=%s} %d\n", metric.name, promLabel(sink.Name), metric.value(sink))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeHTTP serves the counters in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.WritePrometheus(w)
+}
+
+// MetricsHandler returns an http.Handler that serves the metrics of the current
+// global logger in the Prometheus text exposition format, see Metrics.ServeHTTP.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := globalMetrics()
+		if m == nil {
+			writeJSON(w, http.StatusNotImplemented, errorResponse{Error: "global logger does not collect metrics"})
+			return
+		}
+		m.ServeHTTP(w, r)
+	})
+}
+
+func globalMetrics() *Metrics {
+	if l, ok := L().(interface{ Metrics() *Metrics }); ok {
+		return l.Metrics()
+	}
+	return nil
+}
+
+// promLabel 按 Prometheus 文本格式转义标签值
+func promLabel(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// meteredWriter 统计写入字节数与错误
+type meteredWriter struct {
+	w        io.Writer
+	counters *sinkCounters
+}
+
+func (w meteredWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.count(n, err)
+	return n, err
+}
+
+func (w meteredWriter) count(n int, err error) {
+	w.counters.bytes.Add(uint64(n))
+	if err != nil {
+		w.counters.errors.Add(1)
+	}
+}
+
+// Unwrap returns the underlying writer.
+func (w meteredWriter) Unwrap() io.Writer {
+	return w.w
+}
+
+// meteredLevelWriter 保留底层 Writer 按级别写入的能力
+type meteredLevelWriter struct {
+	meteredWriter
+	lw LevelWriter
+}
+
+func (w meteredLevelWriter) WriteLevel(level Level, p []byte) (int, error) {
+	n, err := w.lw.WriteLevel(level, p)
+	w.count(n, err)
+	return n, err
+}
+
+// sinkName Sink 未设置名称时使用其序号
+func sinkName(sink Sink, i int) string {
+	if sink.Name != "" {
+		return sink.Name
+	}
+	return strconv.Itoa(i)
+}
@@ -0,0 +1,90 @@
+package xlog
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestMetrics(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			metrics := NewMetrics()
+			l, err := New(Config{
+				Backend: backend,
+				Level:   InfoLevel,
+				Metrics: metrics,
+				Sinks: []Sink{
+					{Name: "main", Writer: &out, Level: DebugLevel, Format: FormatJSON},
+					{Name: "broken", Writer: failingWriter{}, Level: ErrorLevel},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			order := l.Named("order")
+			order.Error("failed")
+			order.Errorf("failed %d", 2)
+			l.Info("started")
+			l.Debug("disabled")
+
+			s := metrics.Snapshot()
+			want := []EntryCount{{Logger: "", Level: InfoLevel, Count: 1}, {Logger: "order", Level: ErrorLevel, Count: 2}}
+			if len(s.Entries) != len(want) || s.Entries[0] != want[0] || s.Entries[1] != want[1] {
+				t.Fatalf("entries = %+v, want %+v", s.Entries, want)
+			}
+			if len(s.Sinks) != 2 || s.Sinks[0].Name != "broken" || s.Sinks[0].Errors != 2 ||
+				s.Sinks[1].Name != "main" || s.Sinks[1].Bytes != uint64(out.Len()) || s.Sinks[1].Errors != 0 {
+				t.Fatalf("unexpected sinks %+v, %d bytes written", s.Sinks, out.Len())
+			}
+
+			rec := httptest.NewRecorder()
+			metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+			for _, line := range []string{
+				"# TYPE xlog_entries_total counter\n",
+				`xlog_entries_total{level="error",logger="order"} 2` + "\n",
+				`xlog_sink_write_errors_total{sink="broken"} 2` + "\n",
+				`xlog_sink_dropped_total{sink="main"} 0` + "\n",
+			} {
+				if !strings.Contains(rec.Body.String(), line) {
+					t.Errorf("expected %q in:\n%s", line, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+// droppingWriter 模拟 xhttp 等自行统计丢弃条数的 Writer
+type droppingWriter struct {
+	bytes.Buffer
+}
+
+func (*droppingWriter) Dropped() uint64 {
+	return 3
+}
+
+func TestMetricsDropped(t *testing.T) {
+	metrics := NewMetrics()
+	l, err := New(Config{
+		Backend: BackendNative,
+		Metrics: metrics,
+		Sinks:   []Sink{{Writer: &droppingWriter{}, Async: &AsyncConfig{}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	// Dropped 穿过 AsyncWriter 与统计包装读取
+	if s := metrics.Snapshot(); len(s.Sinks) != 1 || s.Sinks[0].Name != "0" || s.Sinks[0].Dropped != 3 {
+		t.Fatalf("unexpected sinks %+v", s.Sinks)
+	}
+}
@@ -0,0 +1,36 @@
+package xlog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			level := NewAtomicLevel(InfoLevel)
+			l, err := New(Config{Backend: backend, Format: FormatJSON, Outputs: []io.Writer{&out}, AtomicLevel: level})
+			if err != nil {
+				t.Fatal(err)
+			}
+			consumer := l.Named("xkafka").Named("consumer")
+			consumer.With(Any("topic", "orders")).Info("consumer started")
+			l.Named("xgorm").Info("connected")
+
+			level.SetLoggerLevel("xkafka", ErrorLevel)
+			consumer.Info("silenced")
+			consumer.Error("consume failed")
+
+			assertLines(t, "named", out.String(), "consumer started", "connected", "consume failed")
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			for i, name := range []string{"xkafka.consumer", "xgorm", "xkafka.consumer"} {
+				if strings.Count(lines[i], `"logger":`) != 1 || !strings.Contains(lines[i], `"logger":"`+name+`"`) {
+					t.Errorf("line %d: expected logger %q in %s", i, name, lines[i])
+				}
+			}
+		})
+	}
+}
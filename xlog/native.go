@@ -0,0 +1,153 @@
+package xlog
+
+import (
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rabbit-rm/xgo/internal/buffer"
+	"github.com/rabbit-rm/xgo/xlog/internal/sample"
+)
+
+// nativeBufferPool native 后端编码使用的 buffer 池
+var nativeBufferPool = buffer.NewPool()
+
+// newNativeCore 创建不依赖第三方日志库的后端，日志直接编码到池化的 buffer 后写出
+func newNativeCore(_ Config, sinks []Sink) core {
+	c := &nativeCore{
+		sinks:   make([]*nativeSink, 0, len(sinks)),
+		context: make([][]byte, len(sinks)),
+	}
+	for _, sink := range sinks {
+		c.sinks = append(c.sinks, newNativeSink(sink))
+	}
+	return c
+}
+
+type nativeCore struct {
+	sinks []*nativeSink
+	// fields 为 With 添加的字段，仅用于 Filter
+	fields []Field
+	// context 为各 Sink 按自身格式预先编码的 With 字段
+	context [][]byte
+}
+
+func (c *nativeCore) Log(t time.Time, level Level, template, msg string, fields []Field, frame *runtime.Frame) {
+	for i, s := range c.sinks {
+		s.log(nativeEntry{
+			time:    t,
+			level:   level,
+			msg:     msg,
+			frame:   frame,
+			fields:  fields,
+			with:    c.fields,
+			context: c.context[i],
+		}, template, c.fields)
+	}
+}
+
+func (c *nativeCore) With(fields []Field) core {
+	child := &nativeCore{
+		sinks:   c.sinks,
+		fields:  append(slices.Clip(c.fields), fields...),
+		context: make([][]byte, len(c.sinks)),
+	}
+	buf := nativeBufferPool.Get()
+	defer buf.Free()
+	for i, s := range c.sinks {
+		buf.Reset()
+		buf.AppendBytes(c.context[i])
+		s.enc.appendFields(buf, fields)
+		child.context[i] = slices.Clone(buf.Bytes())
+	}
+	return child
+}
+
+func (c *nativeCore) Sync() error {
+	for _, s := range c.sinks {
+		if s.dedup != nil {
+			s.dedup.Flush()
+		}
+	}
+	return nil
+}
+
+// nativeSink 按 Sink 配置过滤、采样与合并后写出
+type nativeSink struct {
+	Sink
+	enc            encoder
+	writeLevel     func(Level, []byte) (int, error)
+	sampler        *sample.Sampler
+	samplingLevels []Level
+	dedup          *sample.Dedup[nativeEntry]
+	dedupLevels    []Level
+
+	mu sync.Mutex
+}
+
+func newNativeSink(sink Sink) *nativeSink {
+	s := &nativeSink{
+		Sink:       sink,
+		enc:        newEncoder(sink),
+		writeLevel: sink.levelWriter(),
+	}
+	if sink.Sampling != nil {
+		s.sampler = sample.NewSampler(sink.Sampling.Tick, sink.Sampling.First, sink.Sampling.Thereafter)
+		s.samplingLevels = sink.Sampling.Levels
+	}
+	if sink.Dedup != nil {
+		s.dedup = sample.NewDedup(sink.Dedup.Window, func(e nativeEntry, repeated int) {
+			e.repeated = repeated
+			s.write(e)
+		})
+		s.dedupLevels = sink.Dedup.Levels
+	}
+	return s
+}
+
+func (s *nativeSink) log(e nativeEntry, template string, with []Field) {
+	if e.level < s.Level {
+		return
+	}
+	if s.Filter != nil && !s.Filter(Entry{
+		Time:    e.time,
+		Level:   e.level,
+		Message: e.msg,
+		Fields:  append(slices.Clip(with), e.fields...),
+	}) {
+		return
+	}
+	if s.sampler != nil && levelIn(s.samplingLevels, e.level) {
+		if template == "" {
+			template = e.msg
+		}
+		if !s.sampler.Sample(e.level.String()+":"+template, e.time) {
+			return
+		}
+	}
+	if s.dedup != nil && levelIn(s.dedupLevels, e.level) &&
+		!s.dedup.Check(e.level.String()+":"+e.msg, e) {
+		return
+	}
+	s.write(e)
+}
+
+func (s *nativeSink) write(e nativeEntry) {
+	buf := nativeBufferPool.Get()
+	defer buf.Free()
+	s.enc.encode(buf, e)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writeLevel != nil {
+		_, _ = s.writeLevel(e.level, buf.Bytes())
+		return
+	}
+	_, _ = s.Writer.Write(buf.Bytes())
+}
+
+// levelIn levels 为空时对所有级别生效
+func levelIn(levels []Level, level Level) bool {
+	return len(levels) == 0 || slices.Contains(levels, level)
+}
@@ -0,0 +1,101 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNativeEncoder(t *testing.T) {
+	var jsonOut, textOut bytes.Buffer
+	l, err := New(Config{
+		Backend: BackendNative,
+		Sinks: []Sink{
+			{Writer: &jsonOut, Format: FormatJSON},
+			{Writer: &textOut, Format: FormatText},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.With(Any("request", "r-1")).Log(InfoLevel, "quote \" backslash \\ newline \n tab \t ctrl \x01 invalid \xff 中文",
+		Any("int", 42),
+		Any("float", math.Inf(1)),
+		Any("duration", time.Second),
+		Any("map", map[string]int{"a": 1}),
+		Any("nil", nil),
+	)
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(jsonOut.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json %q: %v", jsonOut.String(), err)
+	}
+	want := map[string]interface{}{
+		"level":    "info",
+		"msg":      "quote \" backslash \\ newline \n tab \t ctrl \x01 invalid � 中文",
+		"request":  "r-1",
+		"int":      float64(42),
+		"float":    "+Inf",
+		"duration": "1s",
+		"nil":      nil,
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("json %s = %#v, want %#v", k, got[k], v)
+		}
+	}
+	if m, ok := got["map"].(map[string]interface{}); !ok || m["a"] != float64(1) {
+		t.Errorf("json map = %#v", got["map"])
+	}
+
+	text := textOut.String()
+	for _, want := range []string{`level="info"`, `request="r-1"`, `int=42`, `duration="1s"`, `newline \n tab \t`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %s in %s", want, text)
+		}
+	}
+	if strings.Count(text, "\n") != 1 {
+		t.Errorf("expected a single line, got %q", text)
+	}
+}
+
+func TestNativeReservedKeys(t *testing.T) {
+	e := nativeEntry{
+		level:  InfoLevel,
+		msg:    "hello",
+		frame:  &runtime.Frame{File: `/tmp/a"b\c.go`, Line: 7},
+		fields: []Field{{Key: "msg", Value: "user"}, {Key: "time", Value: 1}, {Key: "file", Value: "f"}},
+	}
+	// 与固定字段同名的字段加上 fields. 前缀，调用方路径被转义
+	buf := nativeBufferPool.Get()
+	defer buf.Free()
+	jsonEncoder{}.encode(buf, e)
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json %q: %v", buf.String(), err)
+	}
+	want := map[string]interface{}{
+		"msg": "hello", "file": `/tmp/a"b\c.go:7`,
+		"fields.msg": "user", "fields.time": float64(1), "fields.file": "f",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("json %s = %#v, want %#v", k, got[k], v)
+		}
+	}
+
+	buf.Reset()
+	e.fields = []Field{{Key: "message", Value: "user"}}
+	ecsEncoder{}.encode(buf, e)
+	got = nil
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json %q: %v", buf.String(), err)
+	}
+	if got["message"] != "hello" || got["fields.message"] != "user" {
+		t.Errorf("unexpected ecs entry: %s", buf.String())
+	}
+}
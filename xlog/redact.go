@@ -0,0 +1,403 @@
+package xlog
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redactor 由包含敏感信息的类型实现，日志中使用 Redact 的返回值代替原值
+type Redactor interface {
+	Redact() interface{}
+}
+
+// Redaction 日志脱敏配置，作用于消息文本与字段，在后端编码之前执行
+type Redaction struct {
+	// Keys 敏感字段名，忽略大小写，字段名包含其中任意一个时整个值被替换为 Mask；
+	// 同样作用于字段值中 map 的 key 与结构体的字段名（有 json tag 时同时匹配 tag）
+	Keys []string `yaml:"keys"`
+	// Patterns 作用于消息文本、字符串与 error 字段值，每条日志都会执行；
+	// 为空时消息文本不脱敏，DefaultRedaction 只开启 DSNPattern
+	Patterns []Pattern `yaml:"-"`
+	// Mask 替换敏感内容的文本，默认 ******
+	Mask string `yaml:"mask"`
+}
+
+// Pattern 按正则替换文本中的敏感内容
+type Pattern struct {
+	Regexp *regexp.Regexp
+	// Replacement 替换内容，支持 $1 形式引用分组，为空时使用 Redaction.Mask
+	Replacement string
+	// Valid 不为空时只替换返回 true 的匹配
+	Valid func(match string) bool
+	// Prescan 不为空时先以其快速检查文本，返回 false 时跳过正则匹配
+	Prescan func(s string) bool
+}
+
+// 除 DSNPattern 外，以下 Pattern 需要通过 Redaction.Patterns 显式开启
+var (
+	// DSNPattern 匹配 DSN 与 URL 中的 user:password@，只替换密码，密码中可以包含 @ 与 :；
+	// 不含 @ 与 : 的文本跳过正则匹配
+	DSNPattern = Pattern{
+		Regexp:      regexp.MustCompile(`([\w.+-]+):[^\s/]*@`),
+		Replacement: "${1}:******@",
+		Prescan: func(s string) bool {
+			return strings.IndexByte(s, '@') >= 0 && strings.IndexByte(s, ':') >= 0
+		},
+	}
+	// CardPattern 匹配通过 Luhn 校验的银行卡号
+	//
+	// 约十分之一的 13 到 19 位数字能通过 Luhn 校验，订单号、雪花 ID 等会被误替换
+	CardPattern = Pattern{
+		Regexp:  regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Valid:   luhn,
+		Prescan: func(s string) bool { return digitRun(s, 4) && digitCount(s) >= 13 },
+	}
+	// PhonePattern 匹配中国大陆手机号，保留前三位与后四位
+	PhonePattern = Pattern{
+		Regexp:      regexp.MustCompile(`\b((?:86[ -]?)?1[3-9]\d)\d{4}(\d{4})\b`),
+		Replacement: "${1}****${2}",
+		Prescan:     func(s string) bool { return digitRun(s, 11) },
+	}
+)
+
+// DefaultRedaction returns the Redaction used when Config.Redaction is nil.
+//
+// 默认按字段名脱敏并替换 DSN 中的密码，其他 Pattern 对每条日志执行正则匹配，按需加入 Patterns
+func DefaultRedaction() *Redaction {
+	return &Redaction{
+		Keys:     []string{"password", "token", "secret", "dsn"},
+		Patterns: []Pattern{DSNPattern},
+		Mask:     "******",
+	}
+}
+
+// maxRedactDepth 递归脱敏的最大深度，避免循环引用
+const maxRedactDepth = 8
+
+type redactor struct {
+	keys     []string
+	patterns []Pattern
+	mask     string
+	// nestedTypes 缓存结构体类型是否可能包含需要按 key 脱敏的内容
+	nestedTypes sync.Map
+}
+
+func newRedactor(r *Redaction) *redactor {
+	if r == nil {
+		r = DefaultRedaction()
+	}
+	rd := &redactor{patterns: r.Patterns, mask: r.Mask}
+	if rd.mask == "" {
+		rd.mask = "******"
+	}
+	for _, key := range r.Keys {
+		rd.keys = append(rd.keys, strings.ToLower(key))
+	}
+	return rd
+}
+
+// args 替换实现了 Redactor 的参数并按 key 脱敏 map 与结构体参数，未替换时返回原切片
+func (r *redactor) args(args []interface{}) []interface{} {
+	var redacted []interface{}
+	for i, arg := range args {
+		changed := false
+		if v, ok := arg.(Redactor); ok {
+			arg, changed = v.Redact(), true
+		}
+		switch arg.(type) {
+		case nil, string, bool, int, int64, uint64, float64, []byte, error:
+		default:
+			if v, ok := r.nested(reflect.ValueOf(arg), 0); ok {
+				arg, changed = v.Interface(), true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if redacted == nil {
+			redacted = append([]interface{}(nil), args...)
+		}
+		redacted[i] = arg
+	}
+	if redacted == nil {
+		return args
+	}
+	return redacted
+}
+
+// fields 返回脱敏后的字段，未修改时返回原切片
+func (r *redactor) fields(fields []Field) []Field {
+	var redacted []Field
+	for i, f := range fields {
+		v, ok := r.value(f.Key, f.Value)
+		if !ok {
+			continue
+		}
+		if redacted == nil {
+			redacted = append([]Field(nil), fields...)
+		}
+		redacted[i].Value = v
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// value 返回脱敏后的值，第二个返回值表示是否修改
+func (r *redactor) value(key string, value interface{}) (interface{}, bool) {
+	if r.sensitive(key) {
+		return r.mask, true
+	}
+	changed := false
+	if v, ok := value.(Redactor); ok {
+		value, changed = v.Redact(), true
+	}
+	switch v := value.(type) {
+	case nil, bool, int, int64, uint64, float64, []byte:
+	case string:
+		if s := r.text(v); s != v {
+			return s, true
+		}
+	case error:
+		if s := r.text(v.Error()); s != v.Error() {
+			return errors.New(s), true
+		}
+	default:
+		if nv, ok := r.nested(reflect.ValueOf(value), 0); ok {
+			return nv.Interface(), true
+		}
+	}
+	return value, changed
+}
+
+// nested 按 key 脱敏 map、结构体及其指针、切片中的值，修改时返回副本，原值不变
+func (r *redactor) nested(v reflect.Value, depth int) (reflect.Value, bool) {
+	if depth > maxRedactDepth || !v.IsValid() {
+		return v, false
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		elem, ok := r.nested(v.Elem(), depth+1)
+		if !ok {
+			return v, false
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(elem)
+		return out, true
+	case reflect.Pointer:
+		if v.IsNil() || !r.mayNest(v.Type().Elem()) {
+			return v, false
+		}
+		elem, ok := r.nested(v.Elem(), depth+1)
+		if !ok {
+			return v, false
+		}
+		out := reflect.New(elem.Type())
+		out.Elem().Set(elem)
+		return out, true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Len() == 0 {
+			return v, false
+		}
+		var out reflect.Value
+		for iter := v.MapRange(); iter.Next(); {
+			nv, ok := r.keyed(iter.Key().String(), iter.Value(), depth)
+			if !ok {
+				continue
+			}
+			if !out.IsValid() {
+				out = reflect.MakeMapWithSize(v.Type(), v.Len())
+				for it := v.MapRange(); it.Next(); {
+					out.SetMapIndex(it.Key(), it.Value())
+				}
+			}
+			out.SetMapIndex(iter.Key(), nv)
+		}
+		return out, out.IsValid()
+	case reflect.Struct:
+		t := v.Type()
+		if !r.mayNest(t) {
+			return v, false
+		}
+		var out reflect.Value
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			nv, ok := r.keyed(sf.Name, v.Field(i), depth)
+			if !ok && sf.Tag.Get("json") != "" {
+				nv, ok = r.keyed(strings.Split(sf.Tag.Get("json"), ",")[0], v.Field(i), depth)
+			}
+			if !ok {
+				continue
+			}
+			if !out.IsValid() {
+				out = reflect.New(t).Elem()
+				out.Set(v)
+			}
+			out.Field(i).Set(nv)
+		}
+		return out, out.IsValid()
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 || !r.mayNest(v.Type().Elem()) {
+			return v, false
+		}
+		var out reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			nv, ok := r.nested(v.Index(i), depth+1)
+			if !ok {
+				continue
+			}
+			if !out.IsValid() {
+				if v.Kind() == reflect.Slice {
+					out = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+					reflect.Copy(out, v)
+				} else {
+					out = reflect.New(v.Type()).Elem()
+					out.Set(v)
+				}
+			}
+			out.Index(i).Set(nv)
+		}
+		return out, out.IsValid()
+	}
+	return v, false
+}
+
+// keyed 脱敏 key 对应的值，敏感 key 的字符串替换为 Mask，其他类型替换为零值
+func (r *redactor) keyed(key string, v reflect.Value, depth int) (reflect.Value, bool) {
+	if !r.sensitive(key) {
+		return r.nested(v, depth+1)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(r.mask).Convert(v.Type()), true
+	case reflect.Interface:
+		if mask := reflect.ValueOf(r.mask); mask.Type().AssignableTo(v.Type()) {
+			out := reflect.New(v.Type()).Elem()
+			out.Set(mask)
+			return out, true
+		}
+	}
+	return reflect.Zero(v.Type()), true
+}
+
+// mayNest 判断类型的值中是否可能有需要按 key 脱敏的内容，结构体按字段名与字段类型判断并缓存结果
+func (r *redactor) mayNest(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Map:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return r.mayNest(t.Elem())
+	case reflect.Struct:
+	default:
+		return false
+	}
+	if v, ok := r.nestedTypes.Load(t); ok {
+		return v.(bool)
+	}
+	// 递归类型在判断完成前按可能包含处理
+	r.nestedTypes.Store(t, true)
+	may := false
+	for i := 0; i < t.NumField() && !may; i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		may = r.sensitive(sf.Name) || r.sensitive(strings.Split(sf.Tag.Get("json"), ",")[0]) || r.mayNest(sf.Type)
+	}
+	r.nestedTypes.Store(t, may)
+	return may
+}
+
+func (r *redactor) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// text 依次应用所有 Pattern
+func (r *redactor) text(s string) string {
+	for _, p := range r.patterns {
+		if p.Prescan != nil && !p.Prescan(s) {
+			continue
+		}
+		// 绝大多数日志不含敏感信息，MatchString 不分配内存，先匹配再替换
+		if !p.Regexp.MatchString(s) {
+			continue
+		}
+		replacement := p.Replacement
+		if replacement == "" {
+			replacement = r.mask
+		}
+		if p.Valid == nil {
+			s = p.Regexp.ReplaceAllString(s, replacement)
+			continue
+		}
+		s = p.Regexp.ReplaceAllStringFunc(s, func(match string) string {
+			if !p.Valid(match) {
+				return match
+			}
+			return p.Regexp.ReplaceAllString(match, replacement)
+		})
+	}
+	return s
+}
+
+// digitRun 返回 s 是否包含至少 n 个连续数字
+func digitRun(s string, n int) bool {
+	run := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			if run++; run >= n {
+				return true
+			}
+		} else {
+			run = 0
+		}
+	}
+	return false
+}
+
+func digitCount(s string) int {
+	count := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			count++
+		}
+	}
+	return count
+}
+
+// luhn 校验去除空格与连字符后的卡号
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
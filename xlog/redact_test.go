@@ -0,0 +1,113 @@
+package xlog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rabbit-rm/xgo/database"
+)
+
+func TestRedaction(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			redaction := DefaultRedaction()
+			redaction.Patterns = []Pattern{DSNPattern, CardPattern, PhonePattern}
+			l, err := New(Config{Backend: backend, Format: FormatJSON, Outputs: []io.Writer{&out}, Redaction: redaction})
+			if err != nil {
+				t.Fatal(err)
+			}
+			config := &database.Config{DSN: "root:p@ss@tcp(127.0.0.1:3306)/app"}
+			l.With(Any("access_token", "abc123")).Log(InfoLevel, "connect mysql://root:hunter2@db:3306/app",
+				Any("password", "hunter2"),
+				Any("phone", "13812345678"),
+				Any("config", config),
+				Err(errors.New("card 4111 1111 1111 1111 declined")),
+			)
+			l.Infof("order %d paid by %v", 1700000000000, config)
+
+			output := out.String()
+			for _, secret := range []string{"hunter2", "abc123", "p@ss", "12345678", "4111 1111"} {
+				if strings.Contains(output, secret) {
+					t.Errorf("%q leaked in %s", secret, output)
+				}
+			}
+			for _, kept := range []string{"root:******@", "138****5678", "1700000000000"} {
+				if !strings.Contains(output, kept) {
+					t.Errorf("expected %q in %s", kept, output)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultRedaction(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(Config{Backend: BackendNative, Format: FormatJSON, Outputs: []io.Writer{&out}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 通过 Luhn 校验的订单号与手机号格式的数字默认不替换，敏感字段名与 DSN 中的密码仍然脱敏
+	l.Log(InfoLevel, "order 4111111111111111 created", Any("id", "13812345678"), Any("password", "hunter2"))
+	l.Info("dial mysql://root:s3cret@db:3306/app")
+	output := out.String()
+	for _, kept := range []string{"order 4111111111111111 created", "13812345678", "mysql://root:******@db"} {
+		if !strings.Contains(output, kept) {
+			t.Errorf("expected %q in %s", kept, output)
+		}
+	}
+	for _, secret := range []string{"hunter2", "s3cret"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("%q leaked in %s", secret, output)
+		}
+	}
+}
+
+func TestNestedRedaction(t *testing.T) {
+	type credential struct {
+		User   string
+		Secret string `json:"key"`
+		Extra  map[string]interface{}
+	}
+	var out bytes.Buffer
+	l, err := New(Config{Backend: BackendNative, Format: FormatJSON, Outputs: []io.Writer{&out}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := map[string]string{"host": "db", "password": "hunter2"}
+	cred := &credential{User: "bob", Secret: "s3cret", Extra: map[string]interface{}{"api_token": "abc123", "port": 3306}}
+	l.Log(InfoLevel, "connect", Any("cfg", cfg), Any("cred", cred), Any("list", []interface{}{cfg}))
+	l.Infof("connect %v", cfg)
+
+	output := out.String()
+	for _, secret := range []string{"hunter2", "s3cret", "abc123"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("%q leaked in %s", secret, output)
+		}
+	}
+	for _, kept := range []string{`"host":"db"`, `"User":"bob"`, `"port":3306`} {
+		if !strings.Contains(output, kept) {
+			t.Errorf("expected %q in %s", kept, output)
+		}
+	}
+	// 原值不被修改
+	if cfg["password"] != "hunter2" || cred.Secret != "s3cret" || cred.Extra["api_token"] != "abc123" {
+		t.Errorf("original values were modified: %v %+v", cfg, cred)
+	}
+}
+
+func TestDSNPattern(t *testing.T) {
+	r := newRedactor(&Redaction{Patterns: []Pattern{DSNPattern}})
+	for in, want := range map[string]string{
+		"root:p@ss@tcp(127.0.0.1:3306)/app":       "root:******@tcp(127.0.0.1:3306)/app",
+		"postgres://app:p@ss:w0rd@db:5432/app":    "postgres://app:******@db:5432/app",
+		"dial root:secret@db failed, retry 10:30": "dial root:******@db failed, retry 10:30",
+	} {
+		if got := r.text(in); got != want {
+			t.Errorf("text(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package xlog
+
+import (
+	"time"
+)
+
+// Sampling 日志采样：每个 Tick 内相同级别、相同消息模板的日志先输出 First 条，
+// 之后每 Thereafter 条输出一条，Thereafter 为 0 时丢弃其余日志
+//
+// printf 风格调用以格式串作为模板，其他调用以消息本身作为模板
+type Sampling struct {
+	// Tick 采样周期，为 0 时按 1 秒计算
+	Tick       time.Duration `yaml:"tick"`
+	First      int           `yaml:"first"`
+	Thereafter int           `yaml:"thereafter"`
+	// Levels 采样生效的级别，为空时对所有级别生效
+	Levels []Level `yaml:"levels"`
+}
+
+// Dedup 将连续重复（级别与消息均相同）的日志合并：首条正常输出，
+// 其余被抑制并计数，在出现不同日志或每个 Window 结束时输出一条带 repeated 字段的汇总
+type Dedup struct {
+	// Window 汇总间隔，为 0 时按 1 秒计算
+	Window time.Duration `yaml:"window"`
+	// Levels 合并生效的级别，为空时对所有级别生效
+	Levels []Level `yaml:"levels"`
+}
+
+func convertLevels[T any](levels []Level, convert func(Level) T) []T {
+	if len(levels) == 0 {
+		return nil
+	}
+	converted := make([]T, 0, len(levels))
+	for _, level := range levels {
+		converted = append(converted, convert(level))
+	}
+	return converted
+}
@@ -0,0 +1,53 @@
+package xlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSampling(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := New(Config{
+				Backend: backend,
+				Sinks:   []Sink{{Writer: &buf}},
+				// 仅对 Error 采样，Warn 全部输出
+				Sampling: &Sampling{First: 2, Thereafter: 3, Levels: []Level{ErrorLevel}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 1; i <= 10; i++ {
+				l.Errorf("consume failed: offset %d", i)
+			}
+			l.Warn("warn 1")
+			l.Warn("warn 1")
+			assertLines(t, "output", buf.String(), "offset 1", "offset 2", "offset 5", "offset 8", "warn 1", "warn 1")
+		})
+	}
+}
+
+func TestDedupRepeated(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := New(Config{
+				Backend: backend,
+				Sinks:   []Sink{{Writer: &buf, Format: FormatJSON, Dedup: &Dedup{}}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 5; i++ {
+				l.Error("broker unavailable")
+			}
+			l.Info("broker recovered")
+			assertLines(t, "output", buf.String(), "broker unavailable", "broker unavailable", "broker recovered")
+			if lines := strings.Split(buf.String(), "\n"); !strings.Contains(lines[1], `"repeated":4`) {
+				t.Errorf("expected repeat count in %q", lines[1])
+			}
+		})
+	}
+}
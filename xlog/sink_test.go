@@ -0,0 +1,58 @@
+package xlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSinks(t *testing.T) {
+	for _, backend := range []Backend{BackendLogrus, BackendZap, BackendSlog, BackendNative} {
+		t.Run(string(backend), func(t *testing.T) {
+			var console, file, errFile bytes.Buffer
+			l, err := New(Config{
+				Backend: backend,
+				Level:   DebugLevel,
+				Sinks: []Sink{
+					{Writer: &console, Level: InfoLevel, Format: FormatText},
+					{Writer: &file, Level: DebugLevel, Format: FormatJSON},
+					{Writer: &errFile, Level: ErrorLevel, Format: FormatJSON, Filter: func(e Entry) bool {
+						for _, f := range e.Fields {
+							if f.Key == "ignore" {
+								return false
+							}
+						}
+						return true
+					}},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Debug("debug message")
+			l.Info("info message")
+			l.Error("error message")
+			l.With(Any("ignore", true)).Error("ignored error")
+
+			assertLines(t, "console", console.String(), "info message", "error message", "ignored error")
+			assertLines(t, "file", file.String(), "debug message", "info message", "error message", "ignored error")
+			assertLines(t, "error file", errFile.String(), "error message")
+			if !strings.HasPrefix(file.String(), "{") {
+				t.Errorf("expected json output, got %q", file.String())
+			}
+		})
+	}
+}
+
+func assertLines(t *testing.T, name, output string, messages ...string) {
+	t.Helper()
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != len(messages) {
+		t.Fatalf("%s: expected %d lines, got %q", name, len(messages), output)
+	}
+	for i, msg := range messages {
+		if !strings.Contains(lines[i], msg) {
+			t.Errorf("%s: line %d %q does not contain %q", name, i, lines[i], msg)
+		}
+	}
+}
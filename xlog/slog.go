@@ -0,0 +1,227 @@
+package xlog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rabbit-rm/xgo/xlog/internal/sample"
+	"github.com/rabbit-rm/xgo/xlog/xslog"
+)
+
+func newSlogCore(config Config, sinks []Sink) core {
+	// 级别由 AtomicLevel 统一控制，后端只按 Sink 的级别过滤
+	var opts []xslog.Option
+	if len(sinks) == 1 && sinks[0].isPlain() && !sinks[0].Format.shared() {
+		opts = append(opts,
+			xslog.WithLevel(toSlogLevel(sinks[0].Level)),
+			xslog.WithOutput(sinks[0].Writer),
+		)
+		if sinks[0].Format == FormatJSON {
+			opts = append(opts, xslog.WithJSONHandler())
+		}
+	} else {
+		slogSinks := make([]xslog.Sink, 0, len(sinks))
+		for _, sink := range sinks {
+			slogSink := xslog.Sink{
+				Out:        sink.Writer,
+				WriteLevel: slogWriteLevel(sink.levelWriter()),
+				Level:      toSlogLevel(sink.Level),
+				Filter:     slogFilter(sink.Filter),
+				Sampling:   slogSampling(sink.Sampling),
+				Dedup:      slogDedup(sink.Dedup),
+			}
+			switch sink.Format {
+			case FormatJSON:
+				slogSink.Handler = func(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+					return slog.NewJSONHandler(w, opts)
+				}
+			case FormatConsole, FormatLogfmt, FormatECS:
+				enc := newEncoder(sink)
+				slogSink.Handler = func(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+					return &slogEncoderHandler{w: w, mu: &sync.Mutex{}, opts: *opts, enc: enc}
+				}
+			}
+			slogSinks = append(slogSinks, slogSink)
+		}
+		opts = append(opts, xslog.WithSinks(slogSinks...))
+	}
+	if !config.Caller {
+		opts = append(opts, xslog.DisableSource())
+	}
+	return &slogCore{h: xslog.NewLogger(opts...).Handler()}
+}
+
+type slogCore struct {
+	h slog.Handler
+}
+
+func (c *slogCore) Log(t time.Time, level Level, template, msg string, fields []Field, frame *runtime.Frame) {
+	ctx := context.Background()
+	if template != "" {
+		ctx = sample.WithTemplate(ctx, template)
+	}
+	if !c.h.Enabled(ctx, toSlogLevel(level)) {
+		return
+	}
+	var pc uintptr
+	if frame != nil {
+		pc = frame.PC + 1
+	}
+	r := slog.NewRecord(t, toSlogLevel(level), msg, pc)
+	r.AddAttrs(slogAttrs(fields)...)
+	_ = c.h.Handle(ctx, r)
+}
+
+func (c *slogCore) With(fields []Field) core {
+	return &slogCore{h: c.h.WithAttrs(slogAttrs(fields))}
+}
+
+func (c *slogCore) Sync() error {
+	return xslog.Sync(c.h)
+}
+
+// slogEncoderHandler 以 xlog 编码器实现的格式输出 slog 日志
+type slogEncoderHandler struct {
+	w    io.Writer
+	mu   *sync.Mutex
+	opts slog.HandlerOptions
+	enc  encoder
+	// fields 为 WithAttrs 添加的字段，prefix 为 WithGroup 产生的字段名前缀
+	fields []Field
+	prefix string
+}
+
+func (h *slogEncoderHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *slogEncoderHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make([]Field, 0, len(h.fields)+r.NumAttrs())
+	fields = append(fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, a)
+		return true
+	})
+	entry := nativeEntry{time: r.Time, level: fromSlogRecordLevel(r.Level), msg: r.Message, fields: fields}
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		entry.frame = entryFrame(frame.File, frame.Line, frame.Function)
+	}
+
+	buf := nativeBufferPool.Get()
+	defer buf.Free()
+	h.enc.encode(buf, entry)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *slogEncoderHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	child := *h
+	child.fields = slices.Clip(h.fields)
+	for _, a := range attrs {
+		child.fields = appendAttr(child.fields, h.prefix, a)
+	}
+	return &child
+}
+
+func (h *slogEncoderHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	child := *h
+	child.prefix = h.prefix + name + "."
+	return &child
+}
+
+func slogWriteLevel(write func(Level, []byte) (int, error)) func(slog.Level, []byte) (int, error) {
+	if write == nil {
+		return nil
+	}
+	return func(level slog.Level, p []byte) (int, error) {
+		return write(fromSlogLevel(level), p)
+	}
+}
+
+func slogAttrs(fields []Field) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+	return attrs
+}
+
+func slogFilter(filter func(Entry) bool) func(slog.Record) bool {
+	if filter == nil {
+		return nil
+	}
+	return func(r slog.Record) bool {
+		fields := make([]Field, 0, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			fields = appendAttr(fields, "", a)
+			return true
+		})
+		return filter(Entry{Time: r.Time, Level: fromSlogRecordLevel(r.Level), Message: r.Message, Fields: fields})
+	}
+}
+
+func slogSampling(sampling *Sampling) *xslog.Sampling {
+	if sampling == nil {
+		return nil
+	}
+	return &xslog.Sampling{
+		Tick:       sampling.Tick,
+		First:      sampling.First,
+		Thereafter: sampling.Thereafter,
+		Levels:     convertLevels(sampling.Levels, toSlogLevel),
+	}
+}
+
+func slogDedup(dedup *Dedup) *xslog.Dedup {
+	if dedup == nil {
+		return nil
+	}
+	return &xslog.Dedup{
+		Window: dedup.Window,
+		Levels: convertLevels(dedup.Levels, toSlogLevel),
+	}
+}
+
+// fromSlogRecordLevel 转换 slog 后端写出的记录的级别，包括 xslog 扩展的 Panic 与 Fatal 级别
+func fromSlogRecordLevel(level slog.Level) Level {
+	switch {
+	case level >= xslog.LevelFatal:
+		return FatalLevel
+	case level >= xslog.LevelPanic:
+		return PanicLevel
+	default:
+		return fromSlogLevel(level)
+	}
+}
+
+func toSlogLevel(level Level) slog.Level {
+	switch level {
+	case DebugLevel:
+		return slog.LevelDebug
+	case InfoLevel:
+		return slog.LevelInfo
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	case PanicLevel:
+		return xslog.LevelPanic
+	default:
+		return xslog.LevelFatal
+	}
+}
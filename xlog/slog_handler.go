@@ -0,0 +1,98 @@
+package xlog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogHandler 实现 slog.Handler，将 slog 的日志记录转发到当前生效的 xlog Logger
+//
+//	slog.SetDefault(slog.New(xlog.NewSlogHandler()))
+//
+// 属性转换为 Field，分组以 "group.key" 的形式展开
+type SlogHandler struct {
+	fields []Field
+	prefix string
+}
+
+// NewSlogHandler returns a slog.Handler backed by the global xlog Logger.
+func NewSlogHandler() *SlogHandler {
+	return &SlogHandler{}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return L().Enabled(fromSlogLevel(level))
+}
+
+func (h *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make([]Field, 0, len(h.fields)+r.NumAttrs())
+	fields = append(fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, a)
+		return true
+	})
+	// 使用记录中的时间，延迟处理的记录不会取到写入时的时间
+	if l, ok := L().(timedLogger); ok && !r.Time.IsZero() {
+		l.logAt(r.Time, fromSlogLevel(r.Level), r.Message, fields)
+		return nil
+	}
+	L().Log(fromSlogLevel(r.Level), r.Message, fields...)
+	return nil
+}
+
+// timedLogger 由 New 返回的 Logger 实现，可以指定日志的时间
+type timedLogger interface {
+	logAt(t time.Time, level Level, msg string, fields []Field)
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make([]Field, len(h.fields), len(h.fields)+len(attrs))
+	copy(fields, h.fields)
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.prefix, a)
+	}
+	return &SlogHandler{fields: fields, prefix: h.prefix}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		// 空 key 的分组直接内联到当前层级
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, groupPrefix, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: prefix + a.Key, Value: a.Value.Any()})
+}
+
+func fromSlogLevel(level slog.Level) Level {
+	switch {
+	case level >= slog.LevelError:
+		return ErrorLevel
+	case level >= slog.LevelWarn:
+		return WarnLevel
+	case level >= slog.LevelInfo:
+		return InfoLevel
+	default:
+		return DebugLevel
+	}
+}
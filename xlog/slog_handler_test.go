@@ -0,0 +1,92 @@
+package xlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordEntry struct {
+	level  Level
+	msg    string
+	fields []Field
+}
+
+// recordLogger 记录结构化日志调用，便于断言
+type recordLogger struct {
+	Logger
+	level   Level
+	entries *[]recordEntry
+}
+
+func (r *recordLogger) Log(level Level, msg string, fields ...Field) {
+	*r.entries = append(*r.entries, recordEntry{level: level, msg: msg, fields: fields})
+}
+
+func (r *recordLogger) Enabled(level Level) bool {
+	return level >= r.level
+}
+
+func TestSlogHandler(t *testing.T) {
+	prev := L()
+	defer MustSetLogger(prev)
+
+	var entries []recordEntry
+	MustSetLogger(&recordLogger{level: InfoLevel, entries: &entries})
+
+	l := slog.New(NewSlogHandler())
+	l.Debug("dropped")
+	l.With("app", "xgo").WithGroup("req").Warn("slow", "id", 7, slog.Group("user", "name", "bob"), slog.Group("empty"))
+	l.Error("failed", slog.Any("err", fmt.Errorf("boom")))
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	want := []Field{{Key: "app", Value: "xgo"}, {Key: "req.id", Value: int64(7)}, {Key: "req.user.name", Value: "bob"}}
+	if entries[0].level != WarnLevel || entries[0].msg != "slow" || !reflect.DeepEqual(entries[0].fields, want) {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+	if entries[1].level != ErrorLevel || entries[1].fields[0].Key != "err" {
+		t.Errorf("unexpected entry: %+v", entries[1])
+	}
+}
+
+func TestSlogHandlerRecord(t *testing.T) {
+	prev := L()
+	defer MustSetLogger(prev)
+
+	var out bytes.Buffer
+	var levels []Level
+	l, err := New(Config{Backend: BackendSlog, Level: DebugLevel, Sinks: []Sink{
+		{Writer: &out, Level: DebugLevel, Format: FormatJSON, Filter: func(e Entry) bool {
+			levels = append(levels, e.Level)
+			return true
+		}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	MustSetLogger(l)
+
+	// 延迟处理的记录保留原来的时间
+	r := slog.NewRecord(time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local), slog.LevelInfo, "replayed", 0)
+	if err := NewSlogHandler().Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "2020-01-02") {
+		t.Errorf("record time was not kept: %s", out.String())
+	}
+
+	func() {
+		defer func() { _ = recover() }()
+		l.Panic("boom")
+	}()
+	if want := []Level{InfoLevel, PanicLevel}; !reflect.DeepEqual(levels, want) {
+		t.Errorf("filter levels = %v, want %v", levels, want)
+	}
+}
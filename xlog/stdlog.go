@@ -0,0 +1,90 @@
+package xlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// PrintLogger 将 Print、Printf 与 Println 以固定级别写入 Logger，
+// 满足 sarama.StdLogger 等只提供 Print 系列方法的日志接口
+//
+// level 不应为 PanicLevel 或 FatalLevel，否则每次写入都会 panic 或退出进程
+type PrintLogger struct {
+	l     Logger
+	level Level
+}
+
+// NewPrintLogger creates a PrintLogger writing to l at level.
+func NewPrintLogger(l Logger, level Level) *PrintLogger {
+	return &PrintLogger{l: l, level: level}
+}
+
+func (p *PrintLogger) Print(v ...interface{}) {
+	if p.l.Enabled(p.level) {
+		p.log(fmt.Sprint(v...))
+	}
+}
+
+func (p *PrintLogger) Printf(format string, v ...interface{}) {
+	if p.l.Enabled(p.level) {
+		p.log(fmt.Sprintf(format, v...))
+	}
+}
+
+func (p *PrintLogger) Println(v ...interface{}) {
+	if p.l.Enabled(p.level) {
+		p.log(fmt.Sprintln(v...))
+	}
+}
+
+// log 去掉结尾的换行，其他库的日志通常自带换行
+func (p *PrintLogger) log(msg string) {
+	p.l.Log(p.level, strings.TrimRight(msg, "\r\n"))
+}
+
+// logWriter 按行写入 Logger 的 io.Writer
+type logWriter struct {
+	l     Logger
+	level Level
+}
+
+// NewWriter returns an io.Writer that logs every line written to it as an entry at level,
+// for libraries that accept an io.Writer or a *log.Logger.
+// Each Write is expected to contain whole lines, as the standard log package does.
+func NewWriter(l Logger, level Level) io.Writer {
+	return logWriter{l: l, level: level}
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	if !w.l.Enabled(w.level) {
+		return len(p), nil
+	}
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		if line = bytes.TrimRight(line, "\r"); len(line) > 0 {
+			w.l.Log(w.level, string(line))
+		}
+	}
+	return len(p), nil
+}
+
+// NewStdLog returns a *log.Logger that writes to l at level, the time and
+// caller are added by xlog.
+func NewStdLog(l Logger, level Level) *log.Logger {
+	return log.New(NewWriter(l, level), "", 0)
+}
+
+// RedirectStdLog 将标准库 log 包的全局输出重定向到 l，返回恢复原输出的函数
+func RedirectStdLog(l Logger, level Level) (restore func()) {
+	flags, prefix, out := log.Flags(), log.Prefix(), log.Writer()
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(NewWriter(l, level))
+	return func() {
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		log.SetOutput(out)
+	}
+}
@@ -0,0 +1,58 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStdLog(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(Config{Backend: BackendNative, Level: InfoLevel, Format: FormatJSON, Caller: true, Outputs: []io.Writer{&out}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := func() []map[string]interface{} {
+		t.Helper()
+		var entries []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("invalid json %q: %v", line, err)
+			}
+			entries = append(entries, entry)
+		}
+		out.Reset()
+		return entries
+	}
+
+	_, _, line, _ := runtime.Caller(0)
+	NewStdLog(l.Named("std"), WarnLevel).Printf("a\nb")
+	got := entries()
+	if len(got) != 2 || got[0]["msg"] != "a" || got[1]["msg"] != "b" || got[0]["level"] != "warn" || got[0]["logger"] != "std" {
+		t.Fatalf("unexpected entries %v", got)
+	}
+	if want := "stdlog_test.go:" + strconv.Itoa(line+1); got[0]["file"] != want {
+		t.Errorf("caller = %v, want %s", got[0]["file"], want)
+	}
+
+	restore := RedirectStdLog(l, ErrorLevel)
+	log.Println("from std log")
+	restore()
+	if got := entries(); len(got) != 1 || got[0]["msg"] != "from std log" || got[0]["level"] != "error" {
+		t.Fatalf("unexpected entries %v", got)
+	}
+
+	p := NewPrintLogger(l, InfoLevel)
+	p.Println("connected", 3)
+	p.Printf("retry %d\n", 2)
+	NewPrintLogger(l, DebugLevel).Print("disabled")
+	if got := entries(); len(got) != 2 || got[0]["msg"] != "connected 3" || got[1]["msg"] != "retry 2" {
+		t.Fatalf("unexpected entries %v", got)
+	}
+}
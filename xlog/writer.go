@@ -0,0 +1,60 @@
+package xlog
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// isStdStream 标准输出与标准错误不应被 Sync 或 Close
+func isStdStream(w io.Writer) bool {
+	return w == os.Stdout || w == os.Stderr
+}
+
+func syncWriter(w io.Writer) error {
+	if s, ok := w.(interface{ Sync() error }); ok && !isStdStream(w) {
+		return s.Sync()
+	}
+	return nil
+}
+
+func closeWriter(w io.Writer) error {
+	if c, ok := w.(io.Closer); ok && !isStdStream(w) {
+		// 同一个 Writer 可能被多个 Sink 使用
+		if err := c.Close(); !errors.Is(err, os.ErrClosed) {
+			return err
+		}
+	}
+	return nil
+}
+
+// writerOnly 隐藏 Writer 的 Sync 方法，Sync 由 logger 统一处理
+type writerOnly struct {
+	io.Writer
+}
+
+// isTerminal 判断 w 是否为终端，会逐层 Unwrap 包装的 Writer
+func isTerminal(w io.Writer) bool {
+	for {
+		u, ok := w.(interface{ Unwrap() io.Writer })
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor 输出到终端且未设置 NO_COLOR 时启用颜色，见 https://no-color.org
+func useColor(w io.Writer) bool {
+	return colorEnabled(isTerminal(w))
+}
+
+func colorEnabled(terminal bool) bool {
+	return terminal && os.Getenv("NO_COLOR") == ""
+}
@@ -0,0 +1,96 @@
+package xhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Elasticsearch 按 _bulk API 编码为 NDJSON，没有 @timestamp 字段的日志以 Entry.Time 补充
+type Elasticsearch struct {
+	// Index 索引名称，支持 %Y、%m、%d 与 %H 日期占位符（UTC），如 logs-%Y.%m.%d
+	Index string
+}
+
+func (es Elasticsearch) ContentType() string {
+	return "application/x-ndjson"
+}
+
+func (es Elasticsearch) Encode(w io.Writer, entries []Entry) error {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		action, err := json.Marshal(map[string]map[string]string{
+			"create": {"_index": es.index(e.Time)},
+		})
+		if err != nil {
+			return err
+		}
+		buf.Write(action)
+		buf.WriteByte('\n')
+
+		timestamp := `"@timestamp":"` + e.Time.UTC().Format(time.RFC3339Nano) + `"`
+		line := bytes.TrimSpace(e.Line)
+		switch {
+		case len(line) > 2 && line[0] == '{' && bytes.Contains(line, []byte(`"@timestamp":`)):
+			// FormatECS 等已经包含 @timestamp
+			buf.Write(line)
+		case len(line) > 2 && line[0] == '{':
+			buf.WriteString("{" + timestamp + ",")
+			buf.Write(line[1:])
+		case bytes.Equal(line, []byte("{}")):
+			buf.WriteString("{" + timestamp + "}")
+		default:
+			// 不是 JSON 对象时作为 message 字段
+			msg, _ := json.Marshal(string(line))
+			buf.WriteString("{" + timestamp + `,"message":`)
+			buf.Write(msg)
+			buf.WriteByte('}')
+		}
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// bulkResponse _bulk API 的响应，items 与请求中的日志一一对应
+type bulkResponse struct {
+	Errors bool                        `json:"errors"`
+	Items  []map[string]bulkItemResult `json:"items"`
+}
+
+type bulkItemResult struct {
+	Status int `json:"status"`
+}
+
+// CheckResponse 检查 _bulk 响应中每条日志的状态，429 与 5xx 重试，其余失败的日志丢弃
+func (es Elasticsearch) CheckResponse(resp *http.Response, entries []Entry) (retry []Entry, rejected int) {
+	var result bulkResponse
+	// items 与日志数量不一致时无法对应，视为全部成功
+	if json.NewDecoder(resp.Body).Decode(&result) != nil || !result.Errors || len(result.Items) != len(entries) {
+		return nil, 0
+	}
+	for i, item := range result.Items {
+		for _, r := range item {
+			switch {
+			case r.Status == http.StatusTooManyRequests || r.Status >= 500:
+				retry = append(retry, entries[i])
+			case r.Status >= 300:
+				rejected++
+			}
+		}
+	}
+	return retry, rejected
+}
+
+func (es Elasticsearch) index(t time.Time) string {
+	t = t.UTC()
+	return strings.NewReplacer(
+		"%Y", t.Format("2006"),
+		"%m", t.Format("01"),
+		"%d", t.Format("02"),
+		"%H", t.Format("15"),
+	).Replace(es.Index)
+}
@@ -0,0 +1,89 @@
+package xhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Loki 按 Loki push API 编码，日志按标签分组为 stream，级别总是作为 level 标签
+type Loki struct {
+	// Labels 所有日志共有的标签，如 app、env
+	Labels map[string]string
+	// LabelFields 作为标签的日志字段，字段值应当是取值有限的字符串，如 logger
+	LabelFields []string
+}
+
+func (l Loki) ContentType() string {
+	return "application/json"
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (l Loki) Encode(w io.Writer, entries []Entry) error {
+	var (
+		streams []*lokiStream
+		index   = make(map[string]*lokiStream)
+	)
+	for _, e := range entries {
+		labels := l.labels(e)
+		key := labelsKey(labels)
+		s, ok := index[key]
+		if !ok {
+			s = &lokiStream{Stream: labels}
+			index[key] = s
+			streams = append(streams, s)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(e.Time.UnixNano(), 10), string(e.Line)})
+	}
+	return json.NewEncoder(w).Encode(struct {
+		Streams []*lokiStream `json:"streams"`
+	}{streams})
+}
+
+func (l Loki) labels(e Entry) map[string]string {
+	labels := make(map[string]string, len(l.Labels)+len(l.LabelFields)+1)
+	maps.Copy(labels, l.Labels)
+	labels["level"] = e.Level.String()
+	if len(l.LabelFields) == 0 {
+		return labels
+	}
+	var fields map[string]interface{}
+	if json.Unmarshal(e.Line, &fields) != nil {
+		return labels
+	}
+	for _, name := range l.LabelFields {
+		if v, ok := fields[name]; ok && v != nil {
+			labels[labelName(name)] = fmt.Sprint(v)
+		}
+	}
+	return labels
+}
+
+// labelName Loki 标签名只允许字母、数字与下划线
+func labelName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func labelsKey(labels map[string]string) string {
+	var b strings.Builder
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[k]))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
@@ -0,0 +1,370 @@
+// Package xhttp implements an xlog sink that ships batches of JSON entries
+// over HTTP, with encoders for the Loki push API and the Elasticsearch bulk API.
+package xhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rabbit-rm/xgo/xlog"
+)
+
+// Entry 一条待发送的日志
+type Entry struct {
+	// Time 日志的时间，取自 JSON 中的 time 或 @timestamp 字段，缺失时为写入 Writer 的时间
+	Time  time.Time
+	Level xlog.Level
+	// Line 不含换行的 JSON 日志
+	Line []byte
+}
+
+// Encoder 将一批日志编码为请求体
+type Encoder interface {
+	ContentType() string
+	Encode(w io.Writer, entries []Entry) error
+}
+
+// ResponseChecker 由 Encoder 可选实现，检查 2xx 响应中每条日志的结果，
+// 如 Elasticsearch _bulk API 在部分日志失败时仍返回 200
+type ResponseChecker interface {
+	// CheckResponse 返回需要重试的日志以及被拒绝而丢弃的条数
+	CheckResponse(resp *http.Response, entries []Entry) (retry []Entry, rejected int)
+}
+
+// Config HTTP 输出配置
+type Config struct {
+	// URL 推送地址，如 http://loki:3100/loki/api/v1/push 或 http://es:9200/_bulk
+	URL     string
+	Encoder Encoder
+	// Header 附加的请求头，如 Authorization
+	Header http.Header
+	// Client 为空时使用带 Timeout 的 http.Client
+	Client *http.Client
+	// Timeout 每次请求的超时时间，默认 10 秒
+	Timeout time.Duration
+	// Gzip 是否压缩请求体
+	Gzip bool
+	// BatchSize 与 BatchBytes 为每批的最大条数与字节数，默认 1000 条与 1MiB
+	BatchSize  int
+	BatchBytes int
+	// FlushInterval 未满一批时的发送间隔，默认 1 秒
+	FlushInterval time.Duration
+	// BufferSize 缓冲区可容纳的日志条数，缓冲区满时丢弃，默认 10000
+	BufferSize int
+	// MaxRetries 网络错误、429 与 5xx 响应的最大重试次数，默认 5
+	MaxRetries int
+	// MinBackoff 与 MaxBackoff 为重试的退避间隔，默认 500ms 与 30s
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Writer 将 xlog 以 JSON 格式输出的日志按批发送到 HTTP 服务，
+// 重试耗尽或服务拒绝的日志被丢弃并计入 Dropped
+type Writer struct {
+	config Config
+	client *http.Client
+
+	entries   chan Entry
+	flushes   chan chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+	dropped   atomic.Uint64
+}
+
+// New creates a Writer and starts its sending goroutine.
+func New(config Config) (*Writer, error) {
+	if config.URL == "" {
+		return nil, errors.New("xhttp: url cannot be empty")
+	}
+	if config.Encoder == nil {
+		return nil, errors.New("xhttp: encoder cannot be nil")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1000
+	}
+	if config.BatchBytes <= 0 {
+		config.BatchBytes = 1 << 20
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 10000
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = 5
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = 500 * time.Millisecond
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = max(30*time.Second, config.MinBackoff)
+	}
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+	w := &Writer{
+		config:  config,
+		client:  client,
+		entries: make(chan Entry, config.BufferSize),
+		flushes: make(chan chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Sink 返回以 JSON 格式写入 Writer 的 xlog.Sink
+func (w *Writer) Sink(level xlog.Level) xlog.Sink {
+	return xlog.Sink{Writer: w, Level: level, Format: xlog.FormatJSON}
+}
+
+// Write 按行放入缓冲区，级别取自 JSON 中的 level 或 log.level 字段
+func (w *Writer) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		h := parseHeader(line)
+		level := h.Level
+		if level == nil {
+			level = h.ECSLevel
+		}
+		e := Entry{Time: h.time(), Line: bytes.Clone(line)}
+		if level != nil {
+			e.Level = *level
+		}
+		w.enqueue(e)
+	}
+	return len(p), nil
+}
+
+// WriteLevel implements xlog.LevelWriter.
+func (w *Writer) WriteLevel(level xlog.Level, p []byte) (int, error) {
+	line := bytes.TrimRight(p, "\n")
+	w.enqueue(Entry{Time: parseHeader(line).time(), Level: level, Line: bytes.Clone(line)})
+	return len(p), nil
+}
+
+// header 日志中用于分组与排序的字段
+type header struct {
+	Level     *xlog.Level `json:"level"`
+	ECSLevel  *xlog.Level `json:"log.level"`
+	Time      string      `json:"time"`
+	Timestamp string      `json:"@timestamp"`
+}
+
+func parseHeader(line []byte) header {
+	var h header
+	_ = json.Unmarshal(line, &h)
+	return h
+}
+
+// timeLayouts 各后端 JSON 格式的时间格式，不带时区的按本地时间解析
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// time 返回日志的时间，解析失败时返回当前时间
+func (h header) time() time.Time {
+	for _, s := range []string{h.Time, h.Timestamp} {
+		if s == "" {
+			continue
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Now()
+}
+
+func (w *Writer) enqueue(e Entry) {
+	select {
+	case <-w.done:
+		w.dropped.Add(1)
+		return
+	default:
+	}
+	select {
+	case w.entries <- e:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of entries dropped because the buffer was full
+// or their batch could not be delivered.
+func (w *Writer) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Sync 发送缓冲区中的所有日志
+func (w *Writer) Sync() error {
+	ack := make(chan struct{})
+	select {
+	case w.flushes <- ack:
+		<-ack
+	case <-w.stopped:
+	}
+	return nil
+}
+
+// Close 发送缓冲区中的日志后停止，关闭时发送失败不再重试
+func (w *Writer) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+	})
+	<-w.stopped
+	return nil
+}
+
+func (w *Writer) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	var (
+		batch []Entry
+		size  int
+	)
+	flush := func() {
+		if len(batch) > 0 {
+			w.send(batch)
+		}
+		batch, size = nil, 0
+	}
+	add := func(e Entry) {
+		if len(batch) > 0 && size+len(e.Line) > w.config.BatchBytes {
+			flush()
+		}
+		batch = append(batch, e)
+		size += len(e.Line)
+		if len(batch) >= w.config.BatchSize {
+			flush()
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case e := <-w.entries:
+				add(e)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case e := <-w.entries:
+			add(e)
+		case <-ticker.C:
+			flush()
+		case ack := <-w.flushes:
+			drain()
+			flush()
+			close(ack)
+		case <-w.done:
+			drain()
+			flush()
+			return
+		}
+	}
+}
+
+// send 对网络错误、429 与 5xx 响应按指数退避重试，Encoder 实现 ResponseChecker 时只重试失败的日志
+func (w *Writer) send(batch []Entry) {
+	backoff := w.config.MinBackoff
+	for attempt := 0; ; attempt++ {
+		var body bytes.Buffer
+		if err := w.encode(&body, batch); err != nil {
+			break
+		}
+		pending, retry, err := w.post(body.Bytes(), batch)
+		if err == nil {
+			return
+		}
+		batch = pending
+		if !retry || attempt >= w.config.MaxRetries {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-w.done:
+			w.dropped.Add(uint64(len(batch)))
+			return
+		}
+		backoff = min(backoff*2, w.config.MaxBackoff)
+	}
+	w.dropped.Add(uint64(len(batch)))
+}
+
+func (w *Writer) encode(body *bytes.Buffer, batch []Entry) error {
+	if !w.config.Gzip {
+		return w.config.Encoder.Encode(body, batch)
+	}
+	zw := gzip.NewWriter(body)
+	if err := w.config.Encoder.Encode(zw, batch); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// post 返回未成功发送的日志，retry 表示失败是否可以重试；ResponseChecker 拒绝的日志直接计入 Dropped
+func (w *Writer) post(body []byte, batch []Entry) (pending []Entry, retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return batch, false, err
+	}
+	for k, v := range w.config.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", w.config.Encoder.ContentType())
+	if w.config.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return batch, true, err
+	}
+	defer resp.Body.Close()
+	defer func() { _, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return batch, retry, fmt.Errorf("xhttp: unexpected status %d", resp.StatusCode)
+	}
+	checker, ok := w.config.Encoder.(ResponseChecker)
+	if !ok {
+		return nil, false, nil
+	}
+	pending, rejected := checker.CheckResponse(resp, batch)
+	w.dropped.Add(uint64(rejected))
+	if len(pending) > 0 {
+		return pending, true, fmt.Errorf("xhttp: %d entries failed with a retryable status", len(pending))
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,256 @@
+package xhttp
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rabbit-rm/xgo/xlog"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	bodies [][]byte
+	// status 依次返回的状态码，用完后返回 200
+	status []int
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = zr
+	}
+	data, _ := io.ReadAll(body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := http.StatusOK
+	if len(r.status) > 0 {
+		status, r.status = r.status[0], r.status[1:]
+	}
+	if status == http.StatusOK {
+		r.bodies = append(r.bodies, data)
+	}
+	w.WriteHeader(status)
+}
+
+func (r *recorder) received() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.bodies
+}
+
+func newLogger(t *testing.T, config Config) (xlog.Logger, *Writer) {
+	t.Helper()
+	w, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	l, err := xlog.New(xlog.Config{Backend: xlog.BackendNative, Sinks: []xlog.Sink{w.Sink(xlog.DebugLevel)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, w
+}
+
+func TestLoki(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	l, w := newLogger(t, Config{
+		URL:     srv.URL,
+		Gzip:    true,
+		Encoder: Loki{Labels: map[string]string{"app": "demo"}, LabelFields: []string{"logger"}},
+	})
+	l.Named("order").Log(xlog.InfoLevel, "created", xlog.Any("id", 1))
+	l.Named("order").Log(xlog.InfoLevel, "paid", xlog.Any("id", 1))
+	l.Error("failed")
+	_ = w.Sync()
+
+	bodies := rec.received()
+	if len(bodies) != 1 {
+		t.Fatalf("got %d requests, want 1", len(bodies))
+	}
+	var push struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(bodies[0], &push); err != nil {
+		t.Fatal(err)
+	}
+	if len(push.Streams) != 2 {
+		t.Fatalf("got %d streams, want 2: %s", len(push.Streams), bodies[0])
+	}
+	order, failed := push.Streams[0], push.Streams[1]
+	if order.Stream["app"] != "demo" || order.Stream["level"] != "info" || order.Stream["logger"] != "order" || len(order.Values) != 2 {
+		t.Fatalf("unexpected stream %+v", order)
+	}
+	if failed.Stream["level"] != "error" || failed.Stream["logger"] != "" || !strings.Contains(failed.Values[0][1], `"msg":"failed"`) {
+		t.Fatalf("unexpected stream %+v", failed)
+	}
+}
+
+func TestElasticsearch(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	l, w := newLogger(t, Config{URL: srv.URL, Encoder: Elasticsearch{Index: "logs-%Y.%m.%d"}})
+	l.Log(xlog.WarnLevel, "disk full", xlog.Any("usage", 0.99))
+	_ = w.Sync()
+
+	bodies := rec.received()
+	if len(bodies) != 1 {
+		t.Fatalf("got %d requests, want 1", len(bodies))
+	}
+	sc := bufio.NewScanner(strings.NewReader(string(bodies[0])))
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	index := "logs-" + time.Now().UTC().Format("2006.01.02")
+	if len(lines) != 2 || lines[0] != `{"create":{"_index":"`+index+`"}}` {
+		t.Fatalf("unexpected body %q", bodies[0])
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["@timestamp"] == nil || doc["msg"] != "disk full" || doc["usage"] != 0.99 {
+		t.Fatalf("unexpected document %v", doc)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	rec := &recorder{status: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	l, w := newLogger(t, Config{URL: srv.URL, Encoder: Loki{}, MinBackoff: time.Millisecond})
+	l.Info("hello")
+	_ = w.Sync()
+	if got := len(rec.received()); got != 1 || w.Dropped() != 0 {
+		t.Fatalf("got %d requests and %d dropped, want 1 and 0", got, w.Dropped())
+	}
+
+	// 4xx 不重试
+	rec.status = []int{http.StatusBadRequest}
+	l.Info("rejected")
+	_ = w.Sync()
+	if got := len(rec.received()); got != 1 || w.Dropped() != 1 {
+		t.Fatalf("got %d requests and %d dropped, want 1 and 1", got, w.Dropped())
+	}
+}
+
+func TestBatch(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	l, w := newLogger(t, Config{URL: srv.URL, Encoder: Loki{}, BatchSize: 2, FlushInterval: time.Hour})
+	for i := 0; i < 5; i++ {
+		l.Info("hello")
+	}
+	_ = w.Close()
+	if got := len(rec.received()); got != 3 {
+		t.Fatalf("got %d requests, want 3", got)
+	}
+}
+
+func TestElasticsearchItemErrors(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, _ := io.ReadAll(req.Body)
+		docs := strings.Count(string(data), "\n") / 2
+		mu.Lock()
+		requests = append(requests, docs)
+		first := len(requests) == 1
+		mu.Unlock()
+		if !first {
+			_, _ = io.WriteString(w, `{"errors":false,"items":[{"create":{"status":201}}]}`)
+			return
+		}
+		// 第一条成功，第二条限流，第三条映射冲突
+		_, _ = io.WriteString(w, `{"errors":true,"items":[{"create":{"status":201}},{"create":{"status":429}},{"create":{"status":400}}]}`)
+	}))
+	defer srv.Close()
+
+	l, w := newLogger(t, Config{URL: srv.URL, Encoder: Elasticsearch{Index: "logs"}, MinBackoff: time.Millisecond})
+	l.Info("a")
+	l.Info("b")
+	l.Info("c")
+	_ = w.Sync()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 2 || requests[0] != 3 || requests[1] != 1 {
+		t.Fatalf("documents per request = %v, want [3 1]", requests)
+	}
+	if w.Dropped() != 1 {
+		t.Fatalf("dropped = %d, want 1", w.Dropped())
+	}
+}
+
+func TestEntryTime(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	w, err := New(Config{URL: srv.URL, Encoder: Elasticsearch{Index: "logs-%Y.%m.%d"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	// 事件时间早于写入时间，如经过 AsyncWriter 或重试
+	_, _ = w.Write([]byte(`{"level":"info","time":"2024-05-01T08:00:00.5Z","msg":"json"}` + "\n" +
+		`{"@timestamp":"2024-05-01T08:00:01Z","log.level":"error","message":"ecs"}` + "\n"))
+	_ = w.Sync()
+
+	bodies := rec.received()
+	if len(bodies) != 1 {
+		t.Fatalf("got %d requests, want 1", len(bodies))
+	}
+	lines := strings.Split(strings.TrimSpace(string(bodies[0])), "\n")
+	want := []string{
+		`{"create":{"_index":"logs-2024.05.01"}}`,
+		`{"@timestamp":"2024-05-01T08:00:00.5Z","level":"info","time":"2024-05-01T08:00:00.5Z","msg":"json"}`,
+		`{"create":{"_index":"logs-2024.05.01"}}`,
+		`{"@timestamp":"2024-05-01T08:00:01Z","log.level":"error","message":"ecs"}`,
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected body:\n%s", bodies[0])
+	}
+
+	var entries []Entry
+	for _, line := range []string{`{"level":"warn","time":"2024-05-01T16:00:00"}`, `{"log.level":"error","@timestamp":"2024-05-01T08:00:01Z"}`} {
+		h := parseHeader([]byte(line))
+		entries = append(entries, Entry{Time: h.time()})
+	}
+	if got := entries[0].Time; !got.Equal(time.Date(2024, 5, 1, 16, 0, 0, 0, time.Local)) {
+		t.Errorf("local time = %v", got)
+	}
+	var loki strings.Builder
+	_ = Loki{}.Encode(&loki, entries[1:])
+	if !strings.Contains(loki.String(), `"1714550401000000000"`) {
+		t.Errorf("unexpected loki body %s", loki.String())
+	}
+}
@@ -2,8 +2,10 @@ package xlogrus
 
 import (
 	"bytes"
+	"io"
 	"os"
 
+	"github.com/rabbit-rm/xgo/internal/caller"
 	"github.com/rabbit-rm/xgo/internal/pool"
 	"github.com/sirupsen/logrus"
 )
@@ -16,18 +18,55 @@ func NewLogger(opts ...Option) *logrus.Logger {
 		return &bytes.Buffer{}
 	})
 
-	return &logrus.Logger{
+	logger := &logrus.Logger{
 		Out:          options.Out,
 		Formatter:    options.Formatter,
+		Hooks:        make(logrus.LevelHooks),
 		ReportCaller: options.Caller,
 		Level:        options.Level,
 		BufferPool:   bfPool,
 	}
+	if options.Caller {
+		// 必须先于 sinkHook 执行
+		logger.AddHook(callerHook{})
+	}
+	// logrus 在某个 hook 返回错误后不再执行其余 hook，sinkHook 在写入失败时返回错误，必须放在最后
+	for _, hook := range options.Hooks {
+		logger.AddHook(hook)
+	}
+	if len(options.Sinks) > 0 {
+		// 由 sinkHook 负责格式化与输出，logger 自身不再输出
+		logger.Out = io.Discard
+		logger.Formatter = nopFormatter{}
+		logger.AddHook(newSinkHook(options.Sinks))
+	}
+	return logger
+}
+
+// callerHook 在格式化前修正 logrus 记录的调用方，优先使用 xlog 通过 context 传入的调用方
+type callerHook struct{}
+
+func (callerHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (callerHook) Fire(entry *logrus.Entry) error {
+	if entry.Caller == nil {
+		return nil
+	}
+	frame, ok := caller.FromContext(entry.Context)
+	if !ok {
+		frame, ok = caller.Frame(0)
+	}
+	if ok {
+		entry.Caller = &frame
+	}
+	return nil
 }
 
 func loadOptions(opts ...Option) *option {
 	var options = &option{
-		Formatter: defaultTextFormatter(),
+		Formatter: NewTextFormatter(),
 		Caller:    true,
 		Level:     logrus.InfoLevel,
 		Out:       os.Stdout,
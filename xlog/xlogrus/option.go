@@ -1,15 +1,10 @@
 package xlogrus
 
 import (
-	"fmt"
 	"io"
-	"os"
-	"path/filepath"
 	"runtime"
-	"strings"
 
-	"github.com/rabbit-rm/xgo/internal/pkg"
-	"github.com/rabbit-rm/xgo/internal/stacktrace"
+	"github.com/rabbit-rm/xgo/internal/caller"
 	"github.com/sirupsen/logrus"
 )
 
@@ -28,7 +23,9 @@ type option struct {
 	Formatter logrus.Formatter
 	Caller    bool
 	Level     logrus.Level
-	Out          io.Writer
+	Out       io.Writer
+	Hooks     []logrus.Hook
+	Sinks     []Sink
 }
 
 func WithFormatter(formatter logrus.Formatter) Option {
@@ -43,6 +40,18 @@ func EnableReportCaller() Option {
 	})
 }
 
+func DisableReportCaller() Option {
+	return optionFunc(func(opt *option) {
+		opt.Caller = false
+	})
+}
+
+func WithJSONFormatter() Option {
+	return optionFunc(func(opt *option) {
+		opt.Formatter = NewJSONFormatter()
+	})
+}
+
 func WithLevel(level logrus.Level) Option {
 	return optionFunc(func(opt *option) {
 		opt.Level = level
@@ -55,9 +64,24 @@ func WithOut(out io.Writer) Option {
 	})
 }
 
+// WithHooks 添加 logrus hook，在 Sink 输出之前执行，Sink 写入失败不影响这些 hook
+func WithHooks(hooks ...logrus.Hook) Option {
+	return optionFunc(func(opt *option) {
+		opt.Hooks = append(opt.Hooks, hooks...)
+	})
+}
+
+// WithSinks 输出到多个 Sink，设置后忽略 Out 与 Formatter
+func WithSinks(sinks ...Sink) Option {
+	return optionFunc(func(opt *option) {
+		opt.Sinks = append(opt.Sinks, sinks...)
+	})
+}
+
 const defaultTimeFormatLayer = "2006-01-02T15:04:05"
 
-func defaultTextFormatter() *logrus.TextFormatter {
+// NewTextFormatter returns the default text formatter
+func NewTextFormatter() *logrus.TextFormatter {
 	return &logrus.TextFormatter{
 		ForceQuote:       true,
 		TimestampFormat:  defaultTimeFormatLayer,
@@ -65,55 +89,24 @@ func defaultTextFormatter() *logrus.TextFormatter {
 	}
 }
 
-func defaultJsonFormatter() *logrus.JSONFormatter {
+// NewJSONFormatter returns the default JSON formatter
+func NewJSONFormatter() *logrus.JSONFormatter {
 	return &logrus.JSONFormatter{
 		TimestampFormat:  defaultTimeFormatLayer,
 		CallerPrettyfier: callerPretty,
 	}
 }
 
-// 自定义调用堆栈输出
-func callerPretty(_ *runtime.Frame) (function string, file string) {
-	frame := getCaller()
-	file, line := frame.File, frame.Line
-	dir, err := os.Getwd()
-	if err != nil {
-		return "", fmt.Sprintf("%s:%d", file, line)
+// 自定义调用堆栈输出，frame 已由 callerHook 修正
+func callerPretty(frame *runtime.Frame) (function string, file string) {
+	if frame == nil {
+		frame = getCaller()
 	}
-	file = filepath.ToSlash(file)
-	dir = filepath.ToSlash(dir)
-	file = strings.TrimPrefix(file, dir+"/")
-	return "", fmt.Sprintf("%s:%d", file, line)
+	return "", caller.Pretty(frame.File, frame.Line)
 }
 
-var (
-	logrusDepth = 8
-)
-
+// getCaller 从调用栈中跳过 logrus、slog 与 xlog 内部的帧，返回业务调用方
 func getCaller() *runtime.Frame {
-	var f *runtime.Frame
-	stacks := stacktrace.Capture(logrusDepth+2, stacktrace.Full)
-	for frame, more := stacks.Next(); more; frame, more = stacks.Next() {
-		pkgName := getPkgName(frame.Function)
-		f = &frame
-		if strings.HasPrefix(pkgName, pkg.LogrusName()) ||
-			strings.HasPrefix(pkgName, pkg.XLogName()) {
-		} else {
-			break
-		}
-	}
-	return f
-}
-
-func getPkgName(f string) string {
-	for {
-		lastPeriod := strings.LastIndex(f, ".")
-		lastSlash := strings.LastIndex(f, "/")
-		if lastPeriod > lastSlash {
-			f = f[:lastPeriod]
-		} else {
-			break
-		}
-	}
-	return f
+	frame, _ := caller.Frame(1)
+	return &frame
 }
@@ -0,0 +1,188 @@
+package xlogrus
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rabbit-rm/xgo/xlog/internal/sample"
+	"github.com/sirupsen/logrus"
+)
+
+// Sink 独立的日志输出目标，拥有自己的级别、格式与过滤条件
+type Sink struct {
+	Out io.Writer
+	// WriteLevel 不为空时代替 Out，按日志级别写入
+	WriteLevel func(level logrus.Level, p []byte) (int, error)
+	// Level 输出的最低级别
+	Level logrus.Level
+	// Formatter 为空时使用 NewTextFormatter
+	Formatter logrus.Formatter
+	// Filter 返回 false 时该 Sink 丢弃此条日志
+	Filter func(*logrus.Entry) bool
+	// Sampling 采样配置，为空时不采样
+	Sampling *Sampling
+	// Dedup 重复日志合并配置，为空时不合并
+	Dedup *Dedup
+}
+
+// Sampling 每个 Tick 内相同级别、相同消息模板的日志先输出 First 条，之后每 Thereafter 条输出一条
+type Sampling struct {
+	Tick       time.Duration
+	First      int
+	Thereafter int
+	// Levels 采样生效的级别，为空时对所有级别生效
+	Levels []logrus.Level
+}
+
+// Dedup 将连续重复的日志合并，在重复结束或每个 Window 输出一条带重复次数的汇总
+type Dedup struct {
+	Window time.Duration
+	// Levels 合并生效的级别，为空时对所有级别生效
+	Levels []logrus.Level
+}
+
+type sink struct {
+	Sink
+	mu      sync.Mutex
+	sampler *sample.Sampler
+	dedup   *sample.Dedup[*logrus.Entry]
+}
+
+func newSink(s Sink) *sink {
+	if s.Formatter == nil {
+		s.Formatter = NewTextFormatter()
+	}
+	sk := &sink{Sink: s}
+	if s.Sampling != nil {
+		sk.sampler = sample.NewSampler(s.Sampling.Tick, s.Sampling.First, s.Sampling.Thereafter)
+	}
+	if s.Dedup != nil {
+		sk.dedup = sample.NewDedup(s.Dedup.Window, sk.emitRepeated)
+	}
+	return sk
+}
+
+// accept 依次执行级别、过滤、采样与合并判断
+func (s *sink) accept(entry *logrus.Entry) bool {
+	if entry.Level > s.Level || (s.Filter != nil && !s.Filter(entry)) {
+		return false
+	}
+	if s.sampler != nil && containsLevel(s.Sampling.Levels, entry.Level) {
+		template, ok := sample.Template(entry.Context)
+		if !ok {
+			template = entry.Message
+		}
+		if !s.sampler.Sample(entry.Level.String()+":"+template, entry.Time) {
+			return false
+		}
+	}
+	if s.dedup != nil && containsLevel(s.Dedup.Levels, entry.Level) {
+		return s.dedup.Check(entry.Level.String()+":"+entry.Message, entry)
+	}
+	return true
+}
+
+// emitRepeated 输出重复日志的汇总，汇总在异步触发时无法还原调用方，因此不输出 caller
+func (s *sink) emitRepeated(entry *logrus.Entry, repeated int) {
+	summary := entry.Dup()
+	summary.Level = entry.Level
+	summary.Message = entry.Message
+	summary.Data[sample.RepeatedKey] = repeated
+	_ = s.write(summary)
+}
+
+func (s *sink) write(entry *logrus.Entry) error {
+	serialized, err := s.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.WriteLevel != nil {
+		_, err = s.WriteLevel(entry.Level, serialized)
+		return err
+	}
+	_, err = s.Out.Write(serialized)
+	return err
+}
+
+func containsLevel(levels []logrus.Level, level logrus.Level) bool {
+	if len(levels) == 0 {
+		return true
+	}
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// sinkHook 将每条日志分发到所有满足条件的 Sink
+type sinkHook struct {
+	sinks []*sink
+}
+
+func newSinkHook(sinks []Sink) *sinkHook {
+	hook := &sinkHook{}
+	for _, s := range sinks {
+		hook.sinks = append(hook.sinks, newSink(s))
+	}
+	return hook
+}
+
+func (h *sinkHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *sinkHook) Fire(entry *logrus.Entry) error {
+	var errs []error
+	for _, s := range h.sinks {
+		if !s.accept(entry) {
+			continue
+		}
+		if err := s.write(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Sync 输出所有 Sink 中等待合并的重复日志汇总
+func (h *sinkHook) Sync() error {
+	for _, s := range h.sinks {
+		if s.dedup != nil {
+			s.dedup.Flush()
+		}
+	}
+	return nil
+}
+
+// Sync flushes the pending repeated-message summaries of the logger's sinks.
+func Sync(logger *logrus.Logger) error {
+	seen := make(map[*sinkHook]struct{})
+	var errs []error
+	for _, hooks := range logger.Hooks {
+		for _, hook := range hooks {
+			h, ok := hook.(*sinkHook)
+			if !ok {
+				continue
+			}
+			if _, ok := seen[h]; ok {
+				continue
+			}
+			seen[h] = struct{}{}
+			errs = append(errs, h.Sync())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// nopFormatter 配合 sinkHook 使用，避免 logger 自身重复格式化
+type nopFormatter struct{}
+
+func (nopFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
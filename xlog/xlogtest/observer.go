@@ -0,0 +1,301 @@
+// Package xlogtest provides an observer logger for asserting on logs in unit tests.
+package xlogtest
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rabbit-rm/xgo/internal/caller"
+	"github.com/rabbit-rm/xgo/xlog"
+)
+
+// Entry 被记录的日志，Fields 包含 With 添加的字段，字段值保持原样；
+// 内嵌的 Caller 为调用方的 frame，无法获取时为 nil
+type Entry struct {
+	xlog.Entry
+	// CallerPos 调用方 file:line，无法获取时为空
+	CallerPos string
+}
+
+// Logs 记录的日志，可被多个 goroutine 并发写入
+type Logs struct {
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+func (o *Logs) add(e Entry) {
+	o.mu.Lock()
+	o.entries = append(o.entries, e)
+	o.mu.Unlock()
+}
+
+// Len returns the number of captured entries.
+func (o *Logs) Len() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return len(o.entries)
+}
+
+// All returns a copy of all captured entries.
+func (o *Logs) All() []Entry {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return append([]Entry(nil), o.entries...)
+}
+
+// TakeAll returns all captured entries and resets the logs.
+func (o *Logs) TakeAll() []Entry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	entries := o.entries
+	o.entries = nil
+	return entries
+}
+
+// Filter returns the entries for which keep returns true.
+func (o *Logs) Filter(keep func(Entry) bool) *Logs {
+	filtered := &Logs{}
+	for _, e := range o.All() {
+		if keep(e) {
+			filtered.entries = append(filtered.entries, e)
+		}
+	}
+	return filtered
+}
+
+// FilterLevel returns the entries logged at level.
+func (o *Logs) FilterLevel(level xlog.Level) *Logs {
+	return o.Filter(func(e Entry) bool {
+		return e.Level == level
+	})
+}
+
+// FilterMessage returns the entries whose message equals msg.
+func (o *Logs) FilterMessage(msg string) *Logs {
+	return o.Filter(func(e Entry) bool {
+		return e.Message == msg
+	})
+}
+
+// FilterMessageContains returns the entries whose message contains substr.
+func (o *Logs) FilterMessageContains(substr string) *Logs {
+	return o.Filter(func(e Entry) bool {
+		return strings.Contains(e.Message, substr)
+	})
+}
+
+// FilterField returns the entries carrying a field with the given key and value,
+// values are compared with reflect.DeepEqual.
+func (o *Logs) FilterField(key string, value interface{}) *Logs {
+	return o.Filter(func(e Entry) bool {
+		for _, f := range e.Fields {
+			if f.Key == key && reflect.DeepEqual(f.Value, value) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RequireContains fails the test unless an entry at level has a message containing substr.
+func (o *Logs) RequireContains(t testing.TB, level xlog.Level, substr string) {
+	t.Helper()
+	if o.FilterLevel(level).FilterMessageContains(substr).Len() > 0 {
+		return
+	}
+	var b strings.Builder
+	for _, e := range o.All() {
+		fmt.Fprintf(&b, "\n\t%s %q %v", e.Level, e.Message, e.Fields)
+	}
+	t.Fatalf("xlogtest: no %s entry contains %q, captured:%s", level, substr, b.String())
+}
+
+// Logger 将日志记录到 Logs 的 xlog.Logger 实现
+//
+// Fatal 记录日志后调用 runtime.Goexit 结束当前 goroutine，而不是退出进程
+type Logger struct {
+	logs       *Logs
+	level      xlog.Level
+	fields     []xlog.Field
+	name       string
+	callerSkip int
+}
+
+// New creates an observer Logger enabled at level and above, and the Logs it writes to.
+func New(level xlog.Level) (*Logger, *Logs) {
+	logs := &Logs{}
+	return &Logger{logs: logs, level: level}, logs
+}
+
+// Swap installs an observer at DebugLevel as the global logger and restores
+// the previous one when the test finishes, tests using it must not run in parallel.
+func Swap(t testing.TB) *Logs {
+	t.Helper()
+	previous := xlog.L()
+	logger, logs := New(xlog.DebugLevel)
+	xlog.MustSetLogger(logger)
+	t.Cleanup(func() {
+		xlog.MustSetLogger(previous)
+	})
+	return logs
+}
+
+func (l *Logger) Debug(args ...interface{}) {
+	l.log(xlog.DebugLevel, fmt.Sprint(args...), nil)
+}
+
+func (l *Logger) Info(args ...interface{}) {
+	l.log(xlog.InfoLevel, fmt.Sprint(args...), nil)
+}
+
+func (l *Logger) Warn(args ...interface{}) {
+	l.log(xlog.WarnLevel, fmt.Sprint(args...), nil)
+}
+
+func (l *Logger) Error(args ...interface{}) {
+	l.log(xlog.ErrorLevel, fmt.Sprint(args...), nil)
+}
+
+func (l *Logger) Panic(args ...interface{}) {
+	msg := fmt.Sprint(args...)
+	l.log(xlog.PanicLevel, msg, nil)
+	panic(msg)
+}
+
+func (l *Logger) Fatal(args ...interface{}) {
+	l.log(xlog.FatalLevel, fmt.Sprint(args...), nil)
+	runtime.Goexit()
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.log(xlog.DebugLevel, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.log(xlog.InfoLevel, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.log(xlog.WarnLevel, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.log(xlog.ErrorLevel, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *Logger) Panicf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	l.log(xlog.PanicLevel, msg, nil)
+	panic(msg)
+}
+
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.log(xlog.FatalLevel, fmt.Sprintf(format, args...), nil)
+	runtime.Goexit()
+}
+
+func (l *Logger) Debugfn(fn func() string) {
+	l.logfn(xlog.DebugLevel, fn)
+}
+
+func (l *Logger) Infofn(fn func() string) {
+	l.logfn(xlog.InfoLevel, fn)
+}
+
+func (l *Logger) Warnfn(fn func() string) {
+	l.logfn(xlog.WarnLevel, fn)
+}
+
+func (l *Logger) Errorfn(fn func() string) {
+	l.logfn(xlog.ErrorLevel, fn)
+}
+
+func (l *Logger) Log(level xlog.Level, msg string, fields ...xlog.Field) {
+	l.log(level, msg, fields)
+	terminate(level, msg)
+}
+
+func (l *Logger) Logfn(level xlog.Level, msg string, fn func() []xlog.Field) {
+	if l.Enabled(level) {
+		l.log(level, msg, fn())
+	}
+	terminate(level, msg)
+}
+
+func terminate(level xlog.Level, msg string) {
+	switch level {
+	case xlog.PanicLevel:
+		panic(msg)
+	case xlog.FatalLevel:
+		runtime.Goexit()
+	}
+}
+
+func (l *Logger) With(fields ...xlog.Field) xlog.Logger {
+	all := make([]xlog.Field, 0, len(l.fields)+len(fields))
+	all = append(all, l.fields...)
+	all = append(all, xlog.ResolveFields(fields)...)
+	child := *l
+	child.fields = all
+	return &child
+}
+
+// Named records the name as a "logger" field like xlog.Logger.Named.
+func (l *Logger) Named(name string) xlog.Logger {
+	if name == "" {
+		return l
+	}
+	child := *l
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	child.name = name
+	return &child
+}
+
+func (l *Logger) WithCallerSkip(skip int) xlog.Logger {
+	child := *l
+	child.callerSkip += skip
+	return &child
+}
+
+func (l *Logger) Enabled(level xlog.Level) bool {
+	return level >= l.level
+}
+
+func (l *Logger) Sync() error {
+	return nil
+}
+
+func (l *Logger) Close() error {
+	return nil
+}
+
+func (l *Logger) logfn(level xlog.Level, fn func() string) {
+	if l.Enabled(level) {
+		l.log(level, fn(), nil)
+	}
+}
+
+func (l *Logger) log(level xlog.Level, msg string, fields []xlog.Field) {
+	if !l.Enabled(level) {
+		return
+	}
+	e := Entry{Entry: xlog.Entry{Time: time.Now(), Level: level, Message: msg}}
+	e.Fields = make([]xlog.Field, 0, len(l.fields)+len(fields)+1)
+	if l.name != "" {
+		e.Fields = append(e.Fields, xlog.Any("logger", l.name))
+	}
+	e.Fields = append(e.Fields, l.fields...)
+	e.Fields = append(e.Fields, xlog.ResolveFields(fields)...)
+	if frame, ok := caller.FrameSkip(1, l.callerSkip); ok {
+		e.Caller = &frame
+		e.CallerPos = caller.Pretty(frame.File, frame.Line)
+	}
+	l.logs.add(e)
+}
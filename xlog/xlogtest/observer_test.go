@@ -0,0 +1,41 @@
+package xlogtest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rabbit-rm/xgo/xlog"
+)
+
+func TestSwap(t *testing.T) {
+	previous := xlog.L()
+	errNotFound := errors.New("not found")
+
+	t.Run("observe", func(t *testing.T) {
+		logs := Swap(t)
+		xlog.Debugf("user %d", 1)
+		xlog.With(xlog.Any("user_id", 1)).Log(xlog.WarnLevel, "load failed", xlog.Err(errNotFound))
+		xlog.L().Log(xlog.InfoLevel, "roles", xlog.Any("roles", []string{"admin"}))
+
+		logs.RequireContains(t, xlog.WarnLevel, "load failed")
+		warn := logs.FilterLevel(xlog.WarnLevel).All()
+		if len(warn) != 1 || len(warn[0].Fields) != 2 || !errors.Is(warn[0].Fields[1].Value.(error), errNotFound) {
+			t.Fatalf("unexpected entries %+v", warn)
+		}
+		if logs.FilterMessage("user 1").Len() != 1 || logs.FilterField("user_id", 1).Len() != 1 ||
+			logs.FilterField("roles", []string{"admin"}).Len() != 1 {
+			t.Errorf("unexpected entries %+v", logs.All())
+		}
+		if warn[0].CallerPos == "" || warn[0].Caller == nil || !strings.HasSuffix(warn[0].Caller.File, "observer_test.go") {
+			t.Errorf("unexpected caller %q %+v", warn[0].CallerPos, warn[0].Caller)
+		}
+		if n := len(logs.TakeAll()); n != 3 || logs.Len() != 0 {
+			t.Errorf("expected 3 taken entries, got %d", n)
+		}
+	})
+
+	if xlog.L() != previous {
+		t.Error("global logger was not restored")
+	}
+}
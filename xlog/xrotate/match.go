@@ -0,0 +1,112 @@
+package xrotate
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rabbit-rm/xgo/xerror"
+)
+
+// verbPatterns strftime 格式对应的正则，覆盖 NewRotateLogs 与 Writer 支持的格式
+var verbPatterns = map[byte]string{
+	'Y': `\d{4}`,
+	'C': `\d{2}`, 'y': `\d{2}`, 'm': `\d{2}`, 'd': `\d{2}`, 'H': `\d{2}`, 'I': `\d{2}`,
+	'M': `\d{2}`, 'S': `\d{2}`, 'U': `\d{2}`, 'V': `\d{2}`, 'W': `\d{2}`,
+	'e': `[ \d]\d`, 'k': `[ \d]\d`, 'l': `[ \d]\d`,
+	'j': `\d{3}`, 'u': `\d`, 'w': `\d`,
+	'A': `[A-Za-z]+`, 'a': `[A-Za-z]+`, 'B': `[A-Za-z]+`, 'b': `[A-Za-z]+`, 'h': `[A-Za-z]+`, 'Z': `[A-Za-z]+`,
+	'p': `[AP]M`, 'z': `[+-]\d{4}`,
+	'F': `\d{4}-\d{2}-\d{2}`, 'D': `\d{2}/\d{2}/\d{2}`, 'R': `\d{2}:\d{2}`, 'T': `\d{2}:\d{2}:\d{2}`,
+	'r': `\d{2}:\d{2}:\d{2} [AP]M`,
+	'c': `.+?`, 'v': `.+?`, 'x': `.+?`, 'X': `.+?`,
+	'n': `\n`, 't': `\t`, '%': `%`,
+}
+
+// matcher 匹配 pattern 生成的文件：glob 列出候选文件，re 只接受 pattern 展开后
+// 加上可选的 .N 序号与压缩后缀的文件名，避免误删同目录下的其他文件
+type matcher struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+func newMatcher(pattern string) (*matcher, error) {
+	var glob, re strings.Builder
+	re.WriteString(`^(`)
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			glob.WriteByte(pattern[i])
+			re.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			continue
+		}
+		if i+1 == len(pattern) {
+			return nil, xerror.Newf("pattern '%s' ends with '%%'", pattern)
+		}
+		i++
+		verb, ok := verbPatterns[pattern[i]]
+		if !ok {
+			return nil, xerror.Newf("unsupported verb '%%%c' in pattern '%s'", pattern[i], pattern)
+		}
+		if pattern[i] == '%' {
+			glob.WriteByte('%')
+		} else {
+			glob.WriteByte('*')
+		}
+		re.WriteString(verb)
+	}
+	glob.WriteByte('*')
+	re.WriteString(`)(?:\.(\d+))?(?:\.gz|\.zst)?$`)
+	return &matcher{glob: glob.String(), re: regexp.MustCompile(re.String())}, nil
+}
+
+// files 返回匹配的普通文件，按展开后的文件名与序号排序
+func (m *matcher) files() ([]string, error) {
+	matches, err := filepath.Glob(m.glob)
+	if err != nil {
+		return nil, err
+	}
+	type file struct {
+		path string
+		name string
+		gen  int
+	}
+	files := make([]file, 0, len(matches))
+	for _, path := range matches {
+		sub := m.re.FindStringSubmatch(path)
+		if sub == nil {
+			continue
+		}
+		if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		gen, _ := strconv.Atoi(sub[2])
+		files = append(files, file{path: path, name: sub[1], gen: gen})
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].name != files[j].name {
+			return files[i].name < files[j].name
+		}
+		if files[i].gen != files[j].gen {
+			return files[i].gen < files[j].gen
+		}
+		return files[i].path < files[j].path
+	})
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths, nil
+}
+
+// Files 返回 pattern 生成的所有文件，包括 .N 序号与压缩后的文件，不包括软链接与临时文件；
+// pattern 与 NewRotateLogs、NewWriter 相同，时间格式从年到秒排列时结果按写入先后排序
+func Files(pattern string) ([]string, error) {
+	m, err := newMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return m.files()
+}
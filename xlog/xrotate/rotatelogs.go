@@ -45,6 +45,14 @@ type option struct {
 	MaxAge time.Duration
 	// RotationCount is the maximum number of files to keep
 	RotationCount uint
+	// MaxSize is the maximum size in bytes of a file before it is rotated, only used by Writer
+	MaxSize int64
+	// MaxTotalSize is the maximum total size in bytes of all files, only used by Writer
+	MaxTotalSize int64
+	// Compression is the compression of rotated files, only used by Writer
+	Compression Compression
+	// Clock returns the current time, only used by Writer
+	Clock func() time.Time
 }
 
 // WithLinkName sets the name of the symlink to the current log file
@@ -75,18 +83,44 @@ func WithRotationCount(n uint) Option {
 	})
 }
 
+// WithMaxSize sets the maximum size in bytes of a file before it is rotated, 0 means no limit
+func WithMaxSize(size int64) Option {
+	return optionFunc(func(opt *option) {
+		opt.MaxSize = size
+	})
+}
+
+// WithMaxTotalSize sets the maximum total size in bytes of the current and rotated files,
+// the oldest files are removed first, 0 means no limit
+func WithMaxTotalSize(size int64) Option {
+	return optionFunc(func(opt *option) {
+		opt.MaxTotalSize = size
+	})
+}
+
+// WithCompression sets the compression of rotated files
+func WithCompression(c Compression) Option {
+	return optionFunc(func(opt *option) {
+		opt.Compression = c
+	})
+}
+
+// WithClock sets the clock used to name files and expire old ones, default time.Now
+func WithClock(clock func() time.Time) Option {
+	return optionFunc(func(opt *option) {
+		opt.Clock = clock
+	})
+}
+
 func loadOptions(opts ...Option) *option {
 	options := &option{
 		RotationTime: defaultRotateTime,
 		MaxAge:       defaultMaxAge,
+		Clock:        time.Now,
 	}
 	for _, opt := range opts {
 		opt.apply(options)
 	}
-	// rotateCount & maxAge 不能同时设置，默认设置 maxAge
-	if options.RotationCount != 0 {
-		options.MaxAge = 0
-	}
 	return options
 }
 
@@ -110,8 +144,14 @@ func validateRotationTime(pattern string, rotationTime time.Duration) error {
 }
 
 // NewRotateLogs creates a new rotate logs logger with the given options
+//
+// file-rotatelogs 已停止维护，新代码请使用 NewWriter
 func NewRotateLogs(pattern string, options ...Option) (*rotatelogs.RotateLogs, error) {
 	opts := loadOptions(options...)
+	// rotateCount & maxAge 不能同时设置，默认设置 maxAge
+	if opts.RotationCount != 0 {
+		opts.MaxAge = 0
+	}
 
 	// 校验轮转时间
 	if err := validateRotationTime(pattern, opts.RotationTime); err != nil {
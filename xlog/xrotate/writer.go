@@ -0,0 +1,493 @@
+package xrotate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/rabbit-rm/xgo/xerror"
+)
+
+// Compression 轮转后文件的压缩方式
+type Compression string
+
+const (
+	CompressionNone Compression = ""
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+// ext 返回压缩文件的扩展名
+func (c Compression) ext() string {
+	switch c {
+	case CompressionGzip:
+		return ".gz"
+	case CompressionZstd:
+		return ".zst"
+	}
+	return ""
+}
+
+var compressedExts = []string{CompressionGzip.ext(), CompressionZstd.ext()}
+
+// Writer 按时间与大小轮转的日志文件，可代替 NewRotateLogs
+//
+// 文件名由 pattern 按 strftime 格式（%Y %y %m %d %H %M %S %%）生成，时间变化后切换到新文件，
+// 不含时间格式时只按大小轮转；同一时间段内超过 MaxSize 时依次写入 name.1、name.2 …
+//
+// 轮转后的文件在后台压缩，并按 RotationCount、MaxAge（默认 30 天）、MaxTotalSize 清理，
+// 设置 LinkName 时符号链接始终指向当前文件；RotationTime 对 Writer 无效，轮转周期由 pattern 决定
+type Writer struct {
+	pattern string
+	match   *matcher
+	timed   bool
+	opts    *option
+
+	mu        sync.Mutex
+	file      *os.File
+	name      string // pattern 展开后的文件名，不含序号
+	gen       int
+	filename  string
+	size      int64
+	nextCheck time.Time
+	closed    bool
+
+	// current 当前文件名，供后台清理读取；后台任务可能晚于多次轮转执行，不能使用入队时的文件名
+	current atomic.Pointer[string]
+	// jobs 轮转后待压缩的文件，压缩后清理旧文件
+	jobs chan string
+	done chan struct{}
+}
+
+// NewWriter creates a Writer writing to files named by pattern
+func NewWriter(pattern string, options ...Option) (*Writer, error) {
+	opts := loadOptions(options...)
+	timed, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	match, err := newMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+	switch opts.Compression {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+	default:
+		return nil, xerror.Newf("unknown compression %q", opts.Compression)
+	}
+	w := &Writer{
+		pattern: pattern,
+		match:   match,
+		timed:   timed,
+		opts:    opts,
+		jobs:    make(chan string, 16),
+		done:    make(chan struct{}),
+	}
+	if err := w.open(opts.Clock(), false); err != nil {
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// Write 写入当前文件，时间段变化或超过 MaxSize 时先轮转
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	// 上次轮转打开新文件失败时重试
+	if w.file == nil {
+		if err := w.open(w.opts.Clock(), true); err != nil {
+			return 0, err
+		}
+	}
+	if w.timed {
+		if now := w.opts.Clock(); !now.Before(w.nextCheck) {
+			w.nextCheck = now.Truncate(time.Second).Add(time.Second)
+			if expand(w.pattern, now) != w.name {
+				if err := w.rotate(now, false); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+	if w.opts.MaxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.opts.MaxSize {
+		if err := w.rotate(w.opts.Clock(), true); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate 关闭当前文件并切换到下一个文件，可用于收到 SIGHUP 等信号时
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.rotate(w.opts.Clock(), true)
+}
+
+// Filename 返回当前写入的文件名
+func (w *Writer) Filename() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.filename
+}
+
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close 关闭当前文件，并等待后台压缩与清理完成
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+	}
+	close(w.jobs)
+	w.mu.Unlock()
+	<-w.done
+	return err
+}
+
+// rotate 关闭当前文件，next 为 true 时在同一时间段内使用下一个序号
+//
+// 打开新文件失败时 w.file 为 nil，下次 Write 或 rotate 重新打开
+func (w *Writer) rotate(now time.Time, next bool) error {
+	if w.file != nil {
+		err := w.file.Close()
+		w.file = nil
+		// 修改时间取自 Clock，MaxAge 与 Clock 使用同一时间源
+		_ = os.Chtimes(w.filename, now, now)
+		// 关闭失败时文件仍已轮转，继续打开新文件
+		w.jobs <- w.filename
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "xrotate: close %s: %v\n", w.filename, err)
+		}
+	}
+	return w.open(now, next)
+}
+
+// open 打开 now 对应的文件，已有文件时追加到最后一个未压缩且未写满的文件
+func (w *Writer) open(now time.Time, next bool) error {
+	name := expand(w.pattern, now)
+	gen := 0
+	if next && name == w.name {
+		gen = w.gen + 1
+	}
+	if last := lastGeneration(name); last > gen {
+		gen = last
+	}
+	filename := generation(name, gen)
+	if w.compressed(filename) || w.full(filename) {
+		gen++
+		filename = generation(name, gen)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.file, w.name, w.gen, w.filename, w.size = f, name, gen, filename, info.Size()
+	w.nextCheck = now.Truncate(time.Second).Add(time.Second)
+	w.current.Store(&filename)
+	w.link(filename)
+	return nil
+}
+
+func (w *Writer) compressed(filename string) bool {
+	for _, ext := range compressedExts {
+		if _, err := os.Lstat(filename + ext); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Writer) full(filename string) bool {
+	if w.opts.MaxSize <= 0 {
+		return false
+	}
+	info, err := os.Stat(filename)
+	return err == nil && info.Size() >= w.opts.MaxSize
+}
+
+// link 原子地将 LinkName 指向 filename，失败时忽略
+func (w *Writer) link(filename string) {
+	if w.opts.LinkName == "" {
+		return
+	}
+	target, err := filepath.Abs(filename)
+	if err != nil {
+		return
+	}
+	if dir, err := filepath.Abs(filepath.Dir(w.opts.LinkName)); err == nil {
+		if rel, err := filepath.Rel(dir, target); err == nil {
+			target = rel
+		}
+	}
+	tmp := w.opts.LinkName + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, w.opts.LinkName); err != nil {
+		_ = os.Remove(tmp)
+	}
+}
+
+// run 串行执行压缩与清理，错误输出到标准错误
+func (w *Writer) run() {
+	defer close(w.done)
+	for closed := range w.jobs {
+		if w.opts.Compression != CompressionNone {
+			if err := compressFile(closed, w.opts.Compression); err != nil {
+				fmt.Fprintf(os.Stderr, "xrotate: compress %s: %v\n", closed, err)
+			}
+		}
+		if err := w.cleanup(*w.current.Load()); err != nil {
+			fmt.Fprintf(os.Stderr, "xrotate: cleanup %s: %v\n", w.pattern, err)
+		}
+	}
+}
+
+type backup struct {
+	path    string
+	modTime time.Time
+	size    int64
+	// order 为 files 返回的位置，修改时间相同时按文件名与序号排序
+	order int
+}
+
+// cleanup 按 RotationCount、MaxAge、MaxTotalSize 删除旧文件，current 计入总大小但不会被删除，
+// 从新到旧第一个超限的文件及比它更旧的文件都会被删除
+func (w *Writer) cleanup(current string) error {
+	if w.opts.RotationCount == 0 && w.opts.MaxAge <= 0 && w.opts.MaxTotalSize <= 0 {
+		return nil
+	}
+	// 只处理 pattern 生成的文件，同目录下的其他文件不受影响
+	paths, err := w.match.files()
+	if err != nil {
+		return err
+	}
+	var total int64
+	var backups []backup
+	for i, path := range paths {
+		info, err := os.Lstat(path)
+		if err != nil {
+			continue
+		}
+		if path == current {
+			total += info.Size()
+			continue
+		}
+		backups = append(backups, backup{path: path, modTime: info.ModTime(), size: info.Size(), order: i})
+	}
+	// 从新到旧
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].modTime.Equal(backups[j].modTime) {
+			return backups[i].modTime.After(backups[j].modTime)
+		}
+		return backups[i].order > backups[j].order
+	})
+
+	now := w.opts.Clock()
+	expired := false
+	for i, b := range backups {
+		expired = expired ||
+			w.opts.RotationCount > 0 && uint(i) >= w.opts.RotationCount ||
+			w.opts.MaxAge > 0 && now.Sub(b.modTime) > w.opts.MaxAge ||
+			w.opts.MaxTotalSize > 0 && total+b.size > w.opts.MaxTotalSize
+		if !expired {
+			total += b.size
+			continue
+		}
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// compressFile 压缩 filename 后删除原文件，压缩文件保留原文件的修改时间
+func compressFile(filename string, c Compression) error {
+	src, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := filename + c.ext() + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	err = compressTo(dst, src, c)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	_ = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	if err := os.Rename(tmp, filename+c.ext()); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Remove(filename)
+}
+
+func compressTo(dst io.Writer, src io.Reader, c Compression) error {
+	var enc io.WriteCloser
+	switch c {
+	case CompressionZstd:
+		zw, err := zstd.NewWriter(dst)
+		if err != nil {
+			return err
+		}
+		enc = zw
+	default:
+		enc = gzip.NewWriter(dst)
+	}
+	if _, err := io.Copy(enc, src); err != nil {
+		_ = enc.Close()
+		return err
+	}
+	return enc.Close()
+}
+
+// lastGeneration 返回 name 已有文件的最大序号，清理后序号可能不连续
+func lastGeneration(name string) int {
+	matches, _ := filepath.Glob(name + ".*")
+	last := 0
+	for _, path := range matches {
+		suffix := strings.TrimPrefix(path, name+".")
+		for _, ext := range compressedExts {
+			suffix = strings.TrimSuffix(suffix, ext)
+		}
+		if gen, err := strconv.Atoi(suffix); err == nil && gen > last {
+			last = gen
+		}
+	}
+	return last
+}
+
+// generation 返回同一时间段内第 gen 个文件的文件名
+func generation(name string, gen int) string {
+	if gen == 0 {
+		return name
+	}
+	return name + "." + strconv.Itoa(gen)
+}
+
+// parsePattern 校验 pattern 只包含 Writer 支持的时间格式，返回 pattern 是否包含时间格式
+func parsePattern(pattern string) (timed bool, err error) {
+	if pattern == "" {
+		return false, xerror.Newf("pattern cannot be empty")
+	}
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			continue
+		}
+		if i+1 == len(pattern) {
+			return false, xerror.Newf("pattern '%s' ends with '%%'", pattern)
+		}
+		i++
+		switch pattern[i] {
+		case '%':
+		case 'Y', 'y', 'm', 'd', 'H', 'M', 'S':
+			timed = true
+		default:
+			return false, xerror.Newf("unsupported verb '%%%c' in pattern '%s'", pattern[i], pattern)
+		}
+	}
+	return timed, nil
+}
+
+// expand 按 t 展开 pattern 中的时间格式，pattern 已由 parsePattern 校验
+func expand(pattern string, t time.Time) string {
+	if !strings.Contains(pattern, "%") {
+		return pattern
+	}
+	b := make([]byte, 0, len(pattern)+8)
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i+1 == len(pattern) {
+			b = append(b, pattern[i])
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y':
+			b = appendInt(b, t.Year(), 4)
+		case 'y':
+			b = appendInt(b, t.Year()%100, 2)
+		case 'm':
+			b = appendInt(b, int(t.Month()), 2)
+		case 'd':
+			b = appendInt(b, t.Day(), 2)
+		case 'H':
+			b = appendInt(b, t.Hour(), 2)
+		case 'M':
+			b = appendInt(b, t.Minute(), 2)
+		case 'S':
+			b = appendInt(b, t.Second(), 2)
+		default:
+			b = append(b, pattern[i])
+		}
+	}
+	return string(b)
+}
+
+// appendInt 以 width 位补零追加 n
+func appendInt(b []byte, n, width int) []byte {
+	s := strconv.Itoa(n)
+	for i := len(s); i < width; i++ {
+		b = append(b, '0')
+	}
+	return append(b, s...)
+}
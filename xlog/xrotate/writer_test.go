@@ -0,0 +1,345 @@
+package xrotate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type fakeClock struct {
+	now atomic.Int64
+}
+
+func newFakeClock(t time.Time) *fakeClock {
+	c := &fakeClock{}
+	c.now.Store(t.UnixNano())
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	return time.Unix(0, c.now.Load()).UTC()
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.now.Add(int64(d))
+}
+
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func mustWrite(t *testing.T, w *Writer, s string) {
+	t.Helper()
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriterTimeRotation(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock(time.Date(2024, 1, 1, 23, 59, 59, 0, time.UTC))
+	link := filepath.Join(dir, "app.log")
+	w, err := NewWriter(filepath.Join(dir, "app.%Y%m%d.log"), WithClock(clock.Now), WithLinkName(link))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustWrite(t, w, "day1\n")
+	clock.Add(time.Second)
+	mustWrite(t, w, "day2\n")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"app.20240101.log", "app.20240102.log", "app.log"}
+	if got := files(t, dir); !equal(got, want) {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	if target, err := os.Readlink(link); err != nil || target != "app.20240102.log" {
+		t.Errorf("link = %q, %v", target, err)
+	}
+	if b, _ := os.ReadFile(link); string(b) != "day2\n" {
+		t.Errorf("current file = %q", b)
+	}
+}
+
+func TestWriterSizeRotation(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	pattern := filepath.Join(dir, "app.%Y%m%d.log")
+	w, err := NewWriter(pattern, WithClock(clock.Now), WithMaxSize(10), WithRotationCount(2), WithCompression(CompressionGzip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		mustWrite(t, w, s)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 保留当前文件与最近两个压缩后的备份
+	want := []string{"app.20240101.log.1.gz", "app.20240101.log.2.gz", "app.20240101.log.3"}
+	if got := files(t, dir); !equal(got, want) {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	f, err := os.Open(filepath.Join(dir, "app.20240101.log.2.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(r); string(b) != "cccccccc\n" {
+		t.Errorf("backup = %q", b)
+	}
+
+	// 重新打开时继续写入最后一个文件
+	w, err = NewWriter(pattern, WithClock(clock.Now), WithMaxSize(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if got := w.Filename(); got != filepath.Join(dir, "app.20240101.log.3") {
+		t.Errorf("reopened %s", got)
+	}
+}
+
+func TestWriterRetention(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock(time.Now())
+	old := filepath.Join(dir, "app.log.9.zst")
+	if err := os.WriteFile(old, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	past := clock.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewWriter(filepath.Join(dir, "app.log"), WithClock(clock.Now), WithMaxAge(24*time.Hour),
+		WithMaxTotalSize(25), WithCompression(CompressionZstd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 已有 .9 时从 .9 之后开始
+	if got := w.Filename(); got != filepath.Join(dir, "app.log.10") {
+		t.Fatalf("opened %s", got)
+	}
+	data := bytes.Repeat([]byte("x"), 20)
+	for i := 0; i < 3; i++ {
+		mustWrite(t, w, string(data))
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := files(t, dir)
+	if len(got) == 0 || got[len(got)-1] != "app.log.13" {
+		t.Fatalf("files = %v", got)
+	}
+	var total int64
+	for _, name := range got {
+		if name == "app.log.9.zst" {
+			t.Errorf("expired file was not removed")
+		}
+		info, _ := os.Stat(filepath.Join(dir, name))
+		total += info.Size()
+	}
+	if total > 25 {
+		t.Errorf("total size %d exceeds limit, files %v", total, got)
+	}
+	// 最新的备份被保留并可解压
+	b, err := os.ReadFile(filepath.Join(dir, "app.log.12.zst"))
+	if err != nil {
+		t.Fatalf("files = %v: %v", got, err)
+	}
+	dec, _ := zstd.NewReader(nil)
+	defer dec.Close()
+	if out, err := dec.DecodeAll(b, nil); err != nil || !bytes.Equal(out, data) {
+		t.Errorf("decoded %q, %v", out, err)
+	}
+}
+
+func TestWriterCleanupMixedSizes(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock(time.Now())
+	// 从旧到新：小、大、小，.4 为当前文件
+	for i, size := range []int{5, 30, 5, 0} {
+		path := filepath.Join(dir, "app.log."+strconv.Itoa(i+1))
+		if err := os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := clock.Now().Add(time.Duration(i-4) * time.Hour)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := NewWriter(filepath.Join(dir, "app.log"), WithClock(clock.Now), WithMaxTotalSize(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.cleanup(w.Filename()); err != nil {
+		t.Fatal(err)
+	}
+	// .2 超出总大小后，比它更旧的 .1 也被删除
+	if got := files(t, dir); !slices.Equal(got, []string{"app.log.3", "app.log.4"}) {
+		t.Errorf("files = %v", got)
+	}
+}
+
+func TestWriterMaxAgeClock(t *testing.T) {
+	dir := t.TempDir()
+	// Clock 比真实时间晚 10 天，轮转后的文件按 Clock 计算年龄
+	clock := newFakeClock(time.Now().Add(10 * 24 * time.Hour))
+	w, err := NewWriter(filepath.Join(dir, "app.log"), WithClock(clock.Now), WithMaxAge(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustWrite(t, w, "a\n")
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := files(t, dir); len(got) != 2 {
+		t.Errorf("files = %v", got)
+	}
+}
+
+func TestWriterForeignFiles(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock(time.Now())
+	past := clock.Now().Add(-60 * 24 * time.Hour)
+	// 与 pattern 的 glob 匹配但不是 Writer 生成的文件
+	foreign := []string{"other-service.log", "20240101.log.bak", "2024010.log"}
+	for _, name := range append([]string{"20240101.log.3.gz"}, foreign...) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := NewWriter(filepath.Join(dir, "%Y%m%d.log"), WithClock(clock.Now), WithMaxSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustWrite(t, w, "a\n")
+	mustWrite(t, w, "b\n")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := files(t, dir)
+	for _, name := range foreign {
+		if !slices.Contains(got, name) {
+			t.Errorf("foreign file %s was removed, files %v", name, got)
+		}
+	}
+	if slices.Contains(got, "20240101.log.3.gz") {
+		t.Errorf("expired backup was not removed, files %v", got)
+	}
+}
+
+func TestWriterReopen(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock(time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC))
+	w, err := NewWriter(filepath.Join(dir, "%Y", "app.log"), WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	mustWrite(t, w, "2024\n")
+
+	// 新的目录无法创建，轮转失败
+	blocker := filepath.Join(dir, "2025")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	clock.Add(time.Second)
+	if _, err := w.Write([]byte("lost\n")); err == nil {
+		t.Fatal("expected rotation to fail")
+	}
+	if err := os.Remove(blocker); err != nil {
+		t.Fatal(err)
+	}
+	mustWrite(t, w, "2025\n")
+	if b, _ := os.ReadFile(filepath.Join(dir, "2025", "app.log")); string(b) != "2025\n" {
+		t.Errorf("after recovery = %q", b)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "2024", "app.log")); string(b) != "2024\n" {
+		t.Errorf("previous file = %q", b)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"app.20240102.log", "app.20240101.log.10.gz", "app.20240101.log.2",
+		"app.20240101.log", "app.log", "app.20240101.log.tmp", "app.2024011.log", "app.20240101.log_lock"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := Files(filepath.Join(dir, "app.%Y%m%d.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range got {
+		got[i] = filepath.Base(got[i])
+	}
+	want := []string{"app.20240101.log", "app.20240101.log.2", "app.20240101.log.10.gz", "app.20240102.log"}
+	if !equal(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+
+	if got := expand("app.%y%m%d%H%M%S.%%.log", time.Date(2024, 3, 5, 7, 8, 9, 0, time.UTC)); got != "app.240305070809.%.log" {
+		t.Errorf("expand = %q", got)
+	}
+	for _, pattern := range []string{"", "app.%Q.log", "app.%", "app.%F.log"} {
+		if _, err := parsePattern(pattern); err == nil {
+			t.Errorf("expected error for %q", pattern)
+		}
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package xslog
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// slog 没有 Panic 与 Fatal 级别，分别使用 Error+2 与 Error+4 表示
+const (
+	LevelPanic = slog.LevelError + 2
+	LevelFatal = slog.LevelError + 4
+)
+
+const defaultTimeFormatLayer = "2006-01-02T15:04:05"
+
+func NewLogger(opts ...Option) *slog.Logger {
+	options := loadOptions(opts...)
+	if len(options.Sinks) > 0 {
+		return slog.New(newFanoutHandler(options.Sinks, options.Source))
+	}
+
+	handler := options.Handler(options.Out, &slog.HandlerOptions{
+		AddSource:   options.Source,
+		Level:       options.Level,
+		ReplaceAttr: replaceAttr,
+	})
+	return slog.New(handler)
+}
+
+func loadOptions(opts ...Option) *option {
+	options := &option{
+		Level: slog.LevelInfo,
+		Out:   os.Stdout,
+		Handler: func(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+			return slog.NewTextHandler(w, opts)
+		},
+		Source: true,
+	}
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+	return options
+}
+
+// replaceAttr 统一时间、级别与调用方的输出格式
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		if t, ok := a.Value.Any().(time.Time); ok {
+			return slog.String(a.Key, t.Format(defaultTimeFormatLayer))
+		}
+	case slog.LevelKey:
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			switch {
+			case level >= LevelFatal:
+				return slog.String(a.Key, "FATAL")
+			case level >= LevelPanic:
+				return slog.String(a.Key, "PANIC")
+			}
+		}
+	case slog.SourceKey:
+		if source, ok := a.Value.Any().(*slog.Source); ok {
+			return slog.String(a.Key, prettySource(source.File, source.Line))
+		}
+	}
+	return a
+}
+
+func prettySource(file string, line int) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Sprintf("%s:%d", file, line)
+	}
+	file = filepath.ToSlash(file)
+	dir = filepath.ToSlash(dir)
+	file = strings.TrimPrefix(file, dir+"/")
+	return fmt.Sprintf("%s:%d", file, line)
+}
@@ -0,0 +1,64 @@
+package xslog
+
+import (
+	"io"
+	"log/slog"
+)
+
+type Option interface {
+	apply(*option)
+}
+
+type optionFunc func(*option)
+
+func (f optionFunc) apply(opt *option) {
+	f(opt)
+}
+
+// slog Options
+type option struct {
+	Level   slog.Leveler
+	Out     io.Writer
+	Handler func(io.Writer, *slog.HandlerOptions) slog.Handler
+	Source  bool
+	Sinks   []Sink
+}
+
+func WithLevel(level slog.Leveler) Option {
+	return optionFunc(func(opt *option) {
+		opt.Level = level
+	})
+}
+
+func WithOutput(out io.Writer) Option {
+	return optionFunc(func(opt *option) {
+		opt.Out = out
+	})
+}
+
+func WithJSONHandler() Option {
+	return optionFunc(func(opt *option) {
+		opt.Handler = func(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+			return slog.NewJSONHandler(w, opts)
+		}
+	})
+}
+
+func EnableSource() Option {
+	return optionFunc(func(opt *option) {
+		opt.Source = true
+	})
+}
+
+func DisableSource() Option {
+	return optionFunc(func(opt *option) {
+		opt.Source = false
+	})
+}
+
+// WithSinks 输出到多个 Sink，设置后忽略 Level、Out 与 Handler
+func WithSinks(sinks ...Sink) Option {
+	return optionFunc(func(opt *option) {
+		opt.Sinks = append(opt.Sinks, sinks...)
+	})
+}
@@ -0,0 +1,323 @@
+package xslog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/rabbit-rm/xgo/xlog/internal/sample"
+)
+
+// Sink 独立的日志输出目标，拥有自己的级别、Handler 与过滤条件
+type Sink struct {
+	Out io.Writer
+	// WriteLevel 不为空时代替 Out，按日志级别写入
+	WriteLevel func(level slog.Level, p []byte) (int, error)
+	// Level 输出的最低级别
+	Level slog.Leveler
+	// Handler 为空时使用 slog.NewTextHandler
+	Handler func(io.Writer, *slog.HandlerOptions) slog.Handler
+	// Filter 返回 false 时该 Sink 丢弃此条日志，record 包含 WithAttrs 添加的属性
+	Filter func(slog.Record) bool
+	// Sampling 采样配置，为空时不采样
+	Sampling *Sampling
+	// Dedup 重复日志合并配置，为空时不合并
+	Dedup *Dedup
+}
+
+// Sampling 每个 Tick 内相同级别、相同消息模板的日志先输出 First 条，之后每 Thereafter 条输出一条
+type Sampling struct {
+	Tick       time.Duration
+	First      int
+	Thereafter int
+	// Levels 采样生效的级别，为空时对所有级别生效
+	Levels []slog.Level
+}
+
+// Dedup 将连续重复的日志合并，在重复结束或每个 Window 输出一条带重复次数的汇总
+type Dedup struct {
+	Window time.Duration
+	// Levels 合并生效的级别，为空时对所有级别生效
+	Levels []slog.Level
+}
+
+func newFanoutHandler(sinks []Sink, source bool) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(sinks))
+	for _, s := range sinks {
+		newHandler := s.Handler
+		if newHandler == nil {
+			newHandler = func(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+				return slog.NewTextHandler(w, opts)
+			}
+		}
+		opts := &slog.HandlerOptions{
+			AddSource:   source,
+			Level:       s.Level,
+			ReplaceAttr: replaceAttr,
+		}
+		var h slog.Handler
+		if s.WriteLevel != nil {
+			h = newLevelHandler(newHandler, opts, s.WriteLevel)
+		} else {
+			h = newHandler(s.Out, opts)
+		}
+		if s.Filter != nil {
+			h = &filterHandler{Handler: h, filter: s.Filter}
+		}
+		if s.Sampling != nil {
+			h = &samplingHandler{
+				Handler: h,
+				sampler: sample.NewSampler(s.Sampling.Tick, s.Sampling.First, s.Sampling.Thereafter),
+				levels:  s.Sampling.Levels,
+			}
+		}
+		if s.Dedup != nil {
+			h = &dedupHandler{
+				Handler: h,
+				dedup:   sample.NewDedup(s.Dedup.Window, emitRepeated),
+				levels:  s.Dedup.Levels,
+			}
+		}
+		handlers = append(handlers, h)
+	}
+	return fanoutHandler(handlers)
+}
+
+// fanoutHandler 将每条记录分发到所有启用的 Handler
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, r.Level) {
+			if err := handler.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, 0, len(h))
+	for _, handler := range h {
+		handlers = append(handlers, handler.WithAttrs(attrs))
+	}
+	return handlers
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, 0, len(h))
+	for _, handler := range h {
+		handlers = append(handlers, handler.WithGroup(name))
+	}
+	return handlers
+}
+
+func (h fanoutHandler) Sync() error {
+	var errs []error
+	for _, handler := range h {
+		errs = append(errs, Sync(handler))
+	}
+	return errors.Join(errs...)
+}
+
+// levelBounds levelHandler 按级别划分的区间下限
+var levelBounds = [...]slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError, LevelPanic, LevelFatal}
+
+// levelHandler 为每个级别区间创建一个 Handler，使写入时能够带上日志级别
+type levelHandler [len(levelBounds)]slog.Handler
+
+func newLevelHandler(
+	newHandler func(io.Writer, *slog.HandlerOptions) slog.Handler,
+	opts *slog.HandlerOptions,
+	write func(slog.Level, []byte) (int, error),
+) slog.Handler {
+	var h levelHandler
+	for i, level := range levelBounds {
+		h[i] = newHandler(levelWriter{level: level, write: write}, opts)
+	}
+	return h
+}
+
+func (h levelHandler) handler(level slog.Level) slog.Handler {
+	for i := len(levelBounds) - 1; i > 0; i-- {
+		if level >= levelBounds[i] {
+			return h[i]
+		}
+	}
+	return h[0]
+}
+
+func (h levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler(level).Enabled(ctx, level)
+}
+
+func (h levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler(r.Level).Handle(ctx, r)
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	for i := range h {
+		h[i] = h[i].WithAttrs(attrs)
+	}
+	return h
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	for i := range h {
+		h[i] = h[i].WithGroup(name)
+	}
+	return h
+}
+
+type levelWriter struct {
+	level slog.Level
+	write func(slog.Level, []byte) (int, error)
+}
+
+func (w levelWriter) Write(p []byte) (int, error) {
+	return w.write(w.level, p)
+}
+
+// filterHandler 在处理前调用 Filter
+type filterHandler struct {
+	slog.Handler
+	filter func(slog.Record) bool
+	attrs  []slog.Attr
+}
+
+func (h *filterHandler) Handle(ctx context.Context, r slog.Record) error {
+	check := r
+	if len(h.attrs) > 0 {
+		check = slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+		check.AddAttrs(h.attrs...)
+		r.Attrs(func(a slog.Attr) bool {
+			check.AddAttrs(a)
+			return true
+		})
+	}
+	if !h.filter(check) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *filterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	all := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	all = append(all, h.attrs...)
+	all = append(all, attrs...)
+	return &filterHandler{Handler: h.Handler.WithAttrs(attrs), filter: h.filter, attrs: all}
+}
+
+func (h *filterHandler) WithGroup(name string) slog.Handler {
+	return &filterHandler{Handler: h.Handler.WithGroup(name), filter: h.filter, attrs: h.attrs}
+}
+
+// samplingHandler 按消息模板采样，模板由 xlog 通过 context 传入，缺省时使用消息本身
+type samplingHandler struct {
+	slog.Handler
+	sampler *sample.Sampler
+	levels  []slog.Level
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if containsLevel(h.levels, r.Level) {
+		template, ok := sample.Template(ctx)
+		if !ok {
+			template = r.Message
+		}
+		if !h.sampler.Sample(r.Level.String()+":"+template, r.Time) {
+			return nil
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), sampler: h.sampler, levels: h.levels}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), sampler: h.sampler, levels: h.levels}
+}
+
+// repeatedRecord 记录被合并的日志及处理它的 Handler，汇总时使用同一个 Handler 以保留属性
+type repeatedRecord struct {
+	handler slog.Handler
+	record  slog.Record
+}
+
+// dedupHandler 合并连续重复的日志
+type dedupHandler struct {
+	slog.Handler
+	dedup  *sample.Dedup[repeatedRecord]
+	levels []slog.Level
+}
+
+func (h *dedupHandler) Handle(ctx context.Context, r slog.Record) error {
+	if containsLevel(h.levels, r.Level) &&
+		!h.dedup.Check(r.Level.String()+":"+r.Message, repeatedRecord{handler: h.Handler, record: r.Clone()}) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *dedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &dedupHandler{Handler: h.Handler.WithAttrs(attrs), dedup: h.dedup, levels: h.levels}
+}
+
+func (h *dedupHandler) WithGroup(name string) slog.Handler {
+	return &dedupHandler{Handler: h.Handler.WithGroup(name), dedup: h.dedup, levels: h.levels}
+}
+
+// Sync 先输出等待合并的重复日志汇总
+func (h *dedupHandler) Sync() error {
+	h.dedup.Flush()
+	return Sync(h.Handler)
+}
+
+func (h *filterHandler) Sync() error {
+	return Sync(h.Handler)
+}
+
+func (h *samplingHandler) Sync() error {
+	return Sync(h.Handler)
+}
+
+// Sync flushes the pending repeated-message summaries held by handlers created with WithSinks.
+func Sync(h slog.Handler) error {
+	if s, ok := h.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+func emitRepeated(r repeatedRecord, repeated int) {
+	summary := r.record.Clone()
+	summary.AddAttrs(slog.Int(sample.RepeatedKey, repeated))
+	_ = r.handler.Handle(context.Background(), summary)
+}
+
+func containsLevel(levels []slog.Level, level slog.Level) bool {
+	if len(levels) == 0 {
+		return true
+	}
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
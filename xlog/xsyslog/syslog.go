@@ -0,0 +1,460 @@
+// Package xsyslog implements an xlog sink that sends entries to a syslog
+// server using RFC 5424 or RFC 3164 messages over UDP, TCP or unix sockets.
+package xsyslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rabbit-rm/xgo/xlog"
+)
+
+// Format syslog 消息格式
+type Format string
+
+const (
+	RFC5424 Format = "rfc5424"
+	RFC3164 Format = "rfc3164"
+)
+
+// Facility syslog facility
+type Facility int
+
+const (
+	// Kern 为零值，表示未设置 Facility，New 将其替换为 User；内核日志不应由应用程序发送
+	Kern Facility = iota
+	User
+	Mail
+	Daemon
+	Auth
+	Syslog
+	LPR
+	News
+	UUCP
+	Cron
+	AuthPriv
+	FTP
+	_
+	_
+	_
+	_
+	Local0
+	Local1
+	Local2
+	Local3
+	Local4
+	Local5
+	Local6
+	Local7
+)
+
+// DefaultSDID 字段映射到的 structured data ID，32473 为 RFC 5612 中供文档使用的企业号
+const DefaultSDID = "fields@32473"
+
+// Config syslog 输出配置
+type Config struct {
+	// Network 为 udp、tcp、unix 或 unixgram，unix 会先尝试 unixgram；tcp 与 unix 流使用 octet-counting 分帧
+	Network string `yaml:"network"`
+	// Address 服务地址，unix 时为 socket 路径，如 /dev/log
+	Address string `yaml:"address"`
+	// Format 默认 RFC5424
+	Format Format `yaml:"format"`
+	// Facility 默认 User
+	Facility Facility `yaml:"facility"`
+	// AppName 默认为可执行文件名，Hostname 默认为 os.Hostname
+	AppName  string `yaml:"app_name"`
+	Hostname string `yaml:"hostname"`
+	// SDID RFC 5424 中日志字段所在的 structured data ID，默认 DefaultSDID
+	SDID string `yaml:"sd_id"`
+	// Timeout 连接与写入超时，默认 5 秒
+	Timeout time.Duration `yaml:"timeout"`
+	// MinBackoff 与 MaxBackoff 为重连的退避间隔，默认 100ms 与 30s
+	MinBackoff time.Duration `yaml:"min_backoff"`
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+}
+
+// Writer 将 xlog 以 JSON 格式输出的日志转换为 syslog 消息发送：
+// msg 字段作为 MSG，logger 字段作为 MSGID，其余字段作为 structured data（RFC 3164 中追加在 MSG 之后）。
+//
+// 连接断开后按指数退避重连，退避期间的日志被丢弃并计入 Dropped
+type Writer struct {
+	config Config
+	pid    string
+
+	mu       sync.Mutex
+	conn     net.Conn
+	stream   bool
+	backoff  time.Duration
+	nextDial time.Time
+	closed   bool
+	dropped  atomic.Uint64
+}
+
+// New creates a Writer. The connection is established lazily, so New only
+// fails on invalid configuration.
+func New(config Config) (*Writer, error) {
+	switch config.Network {
+	case "udp", "tcp", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("xsyslog: unsupported network %q", config.Network)
+	}
+	switch config.Format {
+	case "":
+		config.Format = RFC5424
+	case RFC5424, RFC3164:
+	default:
+		return nil, fmt.Errorf("xsyslog: unknown format %q", config.Format)
+	}
+	if config.Facility < Kern || config.Facility > Local7 {
+		return nil, fmt.Errorf("xsyslog: invalid facility %d", config.Facility)
+	}
+	if config.Facility == Kern {
+		config.Facility = User
+	}
+	if config.AppName == "" {
+		config.AppName = filepath.Base(os.Args[0])
+	}
+	if config.Hostname == "" {
+		config.Hostname, _ = os.Hostname()
+	}
+	if config.SDID == "" {
+		config.SDID = DefaultSDID
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = 100 * time.Millisecond
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = max(30*time.Second, config.MinBackoff)
+	}
+	return &Writer{config: config, pid: strconv.Itoa(os.Getpid())}, nil
+}
+
+// Sink 返回以 JSON 格式写入 Writer 的 xlog.Sink
+func (w *Writer) Sink(level xlog.Level) xlog.Sink {
+	return xlog.Sink{Writer: w, Level: level, Format: xlog.FormatJSON}
+}
+
+// Write 按行发送日志，级别取自 JSON 中的 level 字段；发送失败的日志计入 Dropped，不返回错误
+func (w *Writer) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			w.send(parseEntry(line, nil))
+		}
+	}
+	return len(p), nil
+}
+
+// WriteLevel implements xlog.LevelWriter.
+func (w *Writer) WriteLevel(level xlog.Level, p []byte) (int, error) {
+	w.send(parseEntry(bytes.TrimRight(p, "\n"), &level))
+	return len(p), nil
+}
+
+// Dropped returns the number of entries dropped while the server was unreachable.
+func (w *Writer) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Close closes the connection, later writes are dropped.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return w.disconnect()
+}
+
+// send 写入失败时立即重连重试一次，仍失败则丢弃
+func (w *Writer) send(e entry) {
+	msg := w.format(e)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		for attempt := 0; attempt < 2; attempt++ {
+			if w.connect() != nil {
+				break
+			}
+			if w.write(msg) == nil {
+				w.backoff = 0
+				return
+			}
+			_ = w.disconnect()
+		}
+	}
+	w.dropped.Add(1)
+}
+
+// connect 需持有锁，退避期间返回错误
+func (w *Writer) connect() error {
+	if w.conn != nil {
+		return nil
+	}
+	if now := time.Now(); now.Before(w.nextDial) {
+		return errors.New("xsyslog: reconnect backoff")
+	}
+	conn, stream, err := w.dial()
+	if err != nil {
+		if w.backoff == 0 {
+			w.backoff = w.config.MinBackoff
+		} else {
+			w.backoff = min(w.backoff*2, w.config.MaxBackoff)
+		}
+		w.nextDial = time.Now().Add(w.backoff)
+		return err
+	}
+	w.conn, w.stream = conn, stream
+	return nil
+}
+
+func (w *Writer) dial() (net.Conn, bool, error) {
+	if w.config.Network == "unix" {
+		// 本地 syslog 通常使用数据报 socket
+		if conn, err := net.DialTimeout("unixgram", w.config.Address, w.config.Timeout); err == nil {
+			return conn, false, nil
+		}
+	}
+	conn, err := net.DialTimeout(w.config.Network, w.config.Address, w.config.Timeout)
+	if err != nil {
+		return nil, false, err
+	}
+	return conn, w.config.Network == "tcp" || w.config.Network == "unix", nil
+}
+
+func (w *Writer) disconnect() error {
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// write 流式连接使用 RFC 6587 的 octet-counting 分帧
+func (w *Writer) write(msg []byte) error {
+	_ = w.conn.SetWriteDeadline(time.Now().Add(w.config.Timeout))
+	if w.stream {
+		msg = append(strconv.AppendInt(nil, int64(len(msg)), 10), append([]byte{' '}, msg...)...)
+	}
+	_, err := w.conn.Write(msg)
+	return err
+}
+
+// field 保持 JSON 中顺序的日志字段，value 为字符串或紧凑的 JSON 文本
+type field struct {
+	key   string
+	value string
+}
+
+type entry struct {
+	// time 日志中的 time 字段，缺失或无法解析时为零值
+	time   time.Time
+	level  xlog.Level
+	msg    string
+	logger string
+	fields []field
+}
+
+// headerKeys 由 syslog 头部表示或无需重复输出的字段
+var headerKeys = map[string]bool{"time": true, "level": true, "msg": true, "logger": true}
+
+// parseEntry 解析一行 JSON 日志，无法解析时整行作为消息；level 为空时取 JSON 中的 level 字段
+func parseEntry(line []byte, level *xlog.Level) entry {
+	e := entry{level: xlog.InfoLevel}
+	if level != nil {
+		e.level = *level
+	}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		e.msg = string(line)
+		return e
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		key, _ := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			break
+		}
+		value := rawString(raw)
+		switch key {
+		case "msg":
+			e.msg = value
+		case "logger":
+			e.logger = value
+		case "time":
+			e.time = parseTime(value)
+		case "level":
+			if level == nil {
+				_ = e.level.UnmarshalText([]byte(value))
+			}
+		}
+		if !headerKeys[key] {
+			e.fields = append(e.fields, field{key: key, value: value})
+		}
+	}
+	return e
+}
+
+// timeLayouts 各后端 JSON 格式的时间格式，不带时区的按本地时间解析
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+func parseTime(s string) time.Time {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// rawString 字符串返回其内容，其他值返回紧凑的 JSON 文本
+func rawString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var buf bytes.Buffer
+	if json.Compact(&buf, raw) == nil {
+		return buf.String()
+	}
+	return string(raw)
+}
+
+// severity 将 xlog 级别映射为 syslog severity
+func severity(level xlog.Level) int {
+	switch level {
+	case xlog.DebugLevel:
+		return 7
+	case xlog.InfoLevel:
+		return 6
+	case xlog.WarnLevel:
+		return 4
+	case xlog.ErrorLevel:
+		return 3
+	case xlog.PanicLevel:
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (w *Writer) format(e entry) []byte {
+	pri := int(w.config.Facility)*8 + severity(e.level)
+	// 使用日志产生的时间，经过异步写入或重连退避后发送时间可能晚很多
+	now := e.time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var b []byte
+	if w.config.Format == RFC3164 {
+		// <PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG key=value...
+		b = fmt.Appendf(b, "<%d>%s %s %s[%s]: ", pri, now.Format(time.Stamp),
+			header(w.config.Hostname, 255), header(w.config.AppName, 32), w.pid)
+		b = append(b, e.msg...)
+		for _, f := range e.fields {
+			b = append(b, ' ')
+			b = append(b, f.key...)
+			b = append(b, '=')
+			b = strconv.AppendQuote(b, f.value)
+		}
+		return b
+	}
+
+	// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+	b = fmt.Appendf(b, "<%d>1 %s %s %s %s %s ", pri, now.Format("2006-01-02T15:04:05.000000Z07:00"),
+		header(w.config.Hostname, 255), header(w.config.AppName, 48), header(w.pid, 128), header(e.logger, 32))
+	b = w.appendSD(b, e.fields)
+	if e.msg != "" {
+		b = append(b, ' ')
+		if !isASCII(e.msg) {
+			// UTF-8 编码的 MSG 必须以 BOM 开头
+			b = append(b, "\xef\xbb\xbf"...)
+		}
+		b = append(b, e.msg...)
+	}
+	return b
+}
+
+// appendSD 字段写入 [SDID name="value" ...]，没有字段时为 NILVALUE
+func (w *Writer) appendSD(b []byte, fields []field) []byte {
+	if len(fields) == 0 {
+		return append(b, '-')
+	}
+	b = append(b, '[')
+	b = append(b, sdName(w.config.SDID)...)
+	for _, f := range fields {
+		name := sdName(f.key)
+		if name == "" {
+			continue
+		}
+		b = append(b, ' ')
+		b = append(b, name...)
+		b = append(b, `="`...)
+		for i := 0; i < len(f.value); i++ {
+			switch c := f.value[i]; c {
+			case '"', '\\', ']':
+				b = append(b, '\\', c)
+			default:
+				b = append(b, c)
+			}
+		}
+		b = append(b, '"')
+	}
+	return append(b, ']')
+}
+
+// header 头部字段只允许可打印的 ASCII 字符且有长度限制，为空时为 NILVALUE
+func header(s string, maxLen int) string {
+	b := make([]byte, 0, min(len(s), maxLen))
+	for i := 0; i < len(s) && len(b) < maxLen; i++ {
+		if c := s[i]; c >= 33 && c <= 126 {
+			b = append(b, c)
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// sdName SD-ID 与 PARAM-NAME 不能包含 '='、空格、']' 与 '"'，最长 32 个字符
+func sdName(s string) string {
+	b := make([]byte, 0, min(len(s), 32))
+	for i := 0; i < len(s) && len(b) < 32; i++ {
+		if c := s[i]; c >= 33 && c <= 126 && c != '=' && c != ']' && c != '"' {
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
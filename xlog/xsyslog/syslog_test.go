@@ -0,0 +1,185 @@
+package xsyslog
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rabbit-rm/xgo/xlog"
+)
+
+func newLogger(t *testing.T, config Config) xlog.Logger {
+	t.Helper()
+	config.AppName, config.Hostname, config.Facility = "app", "host", Local0
+	w, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := xlog.New(xlog.Config{Backend: xlog.BackendNative, Sinks: []xlog.Sink{w.Sink(xlog.DebugLevel)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+func TestUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	l := newLogger(t, Config{Network: "udp", Address: pc.LocalAddr().String()})
+	l.Named("order").Log(xlog.WarnLevel, "payment failed", xlog.Any("id", 42), xlog.Any("note", `a "b" ]`))
+
+	buf := make([]byte, 4096)
+	_ = pc.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	// local0 * 8 + warning
+	if !strings.HasPrefix(msg, "<132>1 ") {
+		t.Fatalf("unexpected header %q", msg)
+	}
+	want := ` order [fields@32473 id="42" note="a \"b\" \]"] payment failed`
+	if !strings.Contains(msg, " host app ") || !strings.HasSuffix(msg, want) {
+		t.Fatalf("got %q, want suffix %q", msg, want)
+	}
+}
+
+func mustAtoi(t *testing.T, s string) int {
+	t.Helper()
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestUnixgramRFC3164(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	pc, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+
+	l := newLogger(t, Config{Network: "unix", Address: path, Format: RFC3164})
+	l.Log(xlog.ErrorLevel, "disk full", xlog.Any("path", "/data"))
+
+	buf := make([]byte, 4096)
+	_ = pc.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<131>") || !strings.Contains(msg, ` host app[`) || !strings.HasSuffix(msg, `]: disk full path="/data"`) {
+		t.Fatalf("unexpected message %q", msg)
+	}
+}
+
+// readFrame 读取一条 octet-counting 分帧的消息
+func readFrame(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	size, err := r.ReadString(' ')
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := make([]byte, mustAtoi(t, strings.TrimSpace(size)))
+	if _, err := r.Read(msg); err != nil {
+		t.Fatal(err)
+	}
+	return string(msg)
+}
+
+func TestTCPReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	accept := func(ln net.Listener) chan net.Conn {
+		ch := make(chan net.Conn, 1)
+		go func() {
+			if conn, err := ln.Accept(); err == nil {
+				ch <- conn
+			}
+		}()
+		return ch
+	}
+
+	l := newLogger(t, Config{Network: "tcp", Address: addr, MinBackoff: 10 * time.Millisecond})
+	conns := accept(ln)
+	l.Info("first")
+	l.Info("second")
+	conn := <-conns
+	r := bufio.NewReader(conn)
+	for _, want := range []string{"first", "second"} {
+		if msg := readFrame(t, r); !strings.HasSuffix(msg, " - "+want) {
+			t.Fatalf("got %q, want %s", msg, want)
+		}
+	}
+
+	// 服务端重启期间的日志被丢弃，退避结束后重新连接
+	_ = conn.Close()
+	_ = ln.Close()
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	<-time.After(20 * time.Millisecond)
+	conns = accept(ln)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		l.Info("after restart")
+		select {
+		case conn = <-conns:
+			defer conn.Close()
+			r = bufio.NewReader(conn)
+		case <-time.After(20 * time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatal("writer did not reconnect")
+			}
+			continue
+		}
+		break
+	}
+	if msg := readFrame(t, r); !strings.HasSuffix(msg, " - after restart") {
+		t.Fatalf("unexpected message after reconnect %q", msg)
+	}
+}
+
+func TestEventTime(t *testing.T) {
+	w := &Writer{config: Config{AppName: "app", Hostname: "host", Facility: Local0}, pid: "1"}
+	// 日志经过异步写入或重连退避后才发送，TIMESTAMP 仍为日志产生的时间
+	e := parseEntry([]byte(`{"time":"2024-05-01T08:00:00.5Z","level":"info","msg":"late"}`), nil)
+	if got := string(w.format(e)); !strings.HasPrefix(got, "<134>1 2024-05-01T08:00:00.500000Z host app 1 ") {
+		t.Errorf("rfc5424 = %q", got)
+	}
+	w.config.Format = RFC3164
+	e = parseEntry([]byte(`{"time":"2024-05-01T08:00:00","level":"info","msg":"local"}`), nil)
+	if got := string(w.format(e)); !strings.HasPrefix(got, "<134>May  1 08:00:00 host app[1]: local") {
+		t.Errorf("rfc3164 = %q", got)
+	}
+}
+
+func TestDefaultFacility(t *testing.T) {
+	w, err := New(Config{Network: "udp", Address: "127.0.0.1:514", AppName: "app", Hostname: "host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 未设置 Facility 时为 user（1），info 的 PRI 为 1*8+6
+	e := parseEntry([]byte(`{"time":"2024-05-01T08:00:00Z","level":"info","msg":"hello"}`), nil)
+	if got := string(w.format(e)); !strings.HasPrefix(got, "<14>1 ") {
+		t.Errorf("rfc5424 = %q", got)
+	}
+}
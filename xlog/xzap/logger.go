@@ -3,6 +3,7 @@ package xzap
 import (
 	"os"
 
+	"github.com/rabbit-rm/xgo/internal/caller"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -24,11 +25,20 @@ func NewLogger(opts ...Option) *zap.SugaredLogger {
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
-	core := zapcore.NewCore(
-		options.Encoder(encoderConfig),
-		zapcore.AddSync(options.Out),
-		options.Level,
-	)
+	var core zapcore.Core
+	if len(options.Sinks) > 0 {
+		core = newTee(encoderConfig, options.Sinks)
+	} else {
+		core = zapcore.NewCore(
+			options.Encoder(encoderConfig),
+			zapcore.AddSync(options.Out),
+			options.Level,
+		)
+	}
+
+	if options.Caller {
+		core = &callerCore{Core: core}
+	}
 
 	logger := zap.New(
 		core,
@@ -40,10 +50,10 @@ func NewLogger(opts ...Option) *zap.SugaredLogger {
 
 func loadOptions(opts ...Option) *option {
 	options := &option{
-		Level:      zap.NewAtomicLevelAt(zap.InfoLevel),
-		Out:        os.Stdout,
-		Encoder:    zapcore.NewConsoleEncoder,
-		ZapOptions: []zap.Option{zap.AddCaller(), zap.AddCallerSkip(1)},
+		Level:   zap.NewAtomicLevelAt(zap.InfoLevel),
+		Out:     os.Stdout,
+		Encoder: zapcore.NewConsoleEncoder,
+		Caller:  true,
 	}
 
 	for _, opt := range opts {
@@ -52,3 +62,23 @@ func loadOptions(opts ...Option) *option {
 
 	return options
 }
+
+// callerCore 在写入前从调用栈解析业务调用方，不依赖固定的 caller skip，
+// 因此无论经由 xlog 的哪一层封装调用，输出的 caller 都一致
+type callerCore struct {
+	zapcore.Core
+}
+
+func (c *callerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &callerCore{Core: c.Core.With(fields)}
+}
+
+func (c *callerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ce = c.Core.Check(ent, ce)
+	if ce != nil {
+		if frame, ok := caller.Frame(1); ok {
+			ce.Entry.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		}
+	}
+	return ce
+}
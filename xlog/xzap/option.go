@@ -18,9 +18,11 @@ func (f optionFunc) apply(opt *option) {
 }
 
 type option struct {
-	Level        zap.AtomicLevel
-	Out          io.Writer
+	Level      zap.AtomicLevel
+	Out        io.Writer
 	Encoder    func(zapcore.EncoderConfig) zapcore.Encoder
+	Caller     bool
+	Sinks      []Sink
 	ZapOptions []zap.Option
 }
 
@@ -44,7 +46,20 @@ func WithJSONEncoder() Option {
 
 func EnableCaller() Option {
 	return optionFunc(func(opt *option) {
-		opt.ZapOptions = append(opt.ZapOptions,zap.AddCaller(),zap.AddCallerSkip(1))
+		opt.Caller = true
+	})
+}
+
+func DisableCaller() Option {
+	return optionFunc(func(opt *option) {
+		opt.Caller = false
+	})
+}
+
+// WithSinks 输出到多个 Sink，设置后忽略 Level、Out 与 Encoder
+func WithSinks(sinks ...Sink) Option {
+	return optionFunc(func(opt *option) {
+		opt.Sinks = append(opt.Sinks, sinks...)
 	})
 }
 
@@ -0,0 +1,241 @@
+package xzap
+
+import (
+	"io"
+	"time"
+
+	"github.com/rabbit-rm/xgo/xlog/internal/sample"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink 独立的日志输出目标，拥有自己的级别、编码器与过滤条件
+type Sink struct {
+	Out io.Writer
+	// WriteLevel 不为空时代替 Out，按日志级别写入
+	WriteLevel func(level zapcore.Level, p []byte) (int, error)
+	// Level 输出的最低级别
+	Level zapcore.LevelEnabler
+	// Encoder 为空时使用 zapcore.NewConsoleEncoder
+	Encoder func(zapcore.EncoderConfig) zapcore.Encoder
+	// Filter 返回 false 时该 Sink 丢弃此条日志，fields 包含 With 添加的字段
+	Filter func(ent zapcore.Entry, fields []zapcore.Field) bool
+	// Sampling 采样配置，为空时不采样
+	Sampling *Sampling
+	// Dedup 重复日志合并配置，为空时不合并
+	Dedup *Dedup
+}
+
+// Sampling 每个 Tick 内相同级别、相同消息模板的日志先输出 First 条，之后每 Thereafter 条输出一条
+type Sampling struct {
+	Tick       time.Duration
+	First      int
+	Thereafter int
+	// Levels 采样生效的级别，为空时对所有级别生效
+	Levels []zapcore.Level
+}
+
+// Dedup 将连续重复的日志合并，在重复结束或每个 Window 输出一条带重复次数的汇总
+type Dedup struct {
+	Window time.Duration
+	// Levels 合并生效的级别，为空时对所有级别生效
+	Levels []zapcore.Level
+}
+
+// newTee 为每个 Sink 创建一个 core，并通过 zapcore.NewTee 组合
+func newTee(encoderConfig zapcore.EncoderConfig, sinks []Sink) zapcore.Core {
+	cores := make([]zapcore.Core, 0, len(sinks))
+	for _, s := range sinks {
+		encoder := s.Encoder
+		if encoder == nil {
+			encoder = zapcore.NewConsoleEncoder
+		}
+		var core zapcore.Core
+		if s.WriteLevel != nil {
+			core = &levelCore{LevelEnabler: s.Level, enc: encoder(encoderConfig), write: s.WriteLevel}
+		} else {
+			core = zapcore.NewCore(encoder(encoderConfig), zapcore.Lock(zapcore.AddSync(s.Out)), s.Level)
+		}
+		if s.Filter != nil {
+			core = &filterCore{Core: core, filter: s.Filter}
+		}
+		if s.Sampling != nil {
+			core = &samplingCore{
+				Core:    core,
+				sampler: sample.NewSampler(s.Sampling.Tick, s.Sampling.First, s.Sampling.Thereafter),
+				levels:  s.Sampling.Levels,
+			}
+		}
+		if s.Dedup != nil {
+			core = &dedupCore{
+				Core:   core,
+				dedup:  sample.NewDedup(s.Dedup.Window, emitRepeated),
+				levels: s.Dedup.Levels,
+			}
+		}
+		cores = append(cores, core)
+	}
+	return zapcore.NewTee(cores...)
+}
+
+// levelCore 与 zapcore.NewCore 创建的 core 相同，但写入时带上日志级别
+type levelCore struct {
+	zapcore.LevelEnabler
+	enc   zapcore.Encoder
+	write func(zapcore.Level, []byte) (int, error)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &levelCore{LevelEnabler: c.LevelEnabler, enc: enc, write: c.write}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *levelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	_, err = c.write(ent.Level, buf.Bytes())
+	buf.Free()
+	return err
+}
+
+func (c *levelCore) Sync() error {
+	return nil
+}
+
+// filterCore 在写入前调用 Filter
+type filterCore struct {
+	zapcore.Core
+	filter  func(zapcore.Entry, []zapcore.Field) bool
+	context []zapcore.Field
+}
+
+func (c *filterCore) With(fields []zapcore.Field) zapcore.Core {
+	context := make([]zapcore.Field, 0, len(c.context)+len(fields))
+	context = append(context, c.context...)
+	context = append(context, fields...)
+	return &filterCore{Core: c.Core.With(fields), filter: c.filter, context: context}
+}
+
+func (c *filterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *filterCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := fields
+	if len(c.context) > 0 {
+		all = make([]zapcore.Field, 0, len(c.context)+len(fields))
+		all = append(all, c.context...)
+		all = append(all, fields...)
+	}
+	if !c.filter(ent, all) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// samplingCore 按消息模板采样，模板由 xlog 通过 Skip 类型的字段传入，缺省时使用消息本身
+type samplingCore struct {
+	zapcore.Core
+	sampler *sample.Sampler
+	levels  []zapcore.Level
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplingCore{Core: c.Core.With(fields), sampler: c.sampler, levels: c.levels}
+}
+
+func (c *samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *samplingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if containsLevel(c.levels, ent.Level) {
+		template := ent.Message
+		for _, f := range fields {
+			if f.Type == zapcore.SkipType && f.Key == sample.TemplateKey {
+				template = f.String
+				break
+			}
+		}
+		if !c.sampler.Sample(ent.Level.String()+":"+template, ent.Time) {
+			return nil
+		}
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// repeatedEntry 记录被合并的日志及写入它的 core，汇总时使用同一个 core 以保留 With 字段
+type repeatedEntry struct {
+	core   zapcore.Core
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+// dedupCore 合并连续重复的日志
+type dedupCore struct {
+	zapcore.Core
+	dedup  *sample.Dedup[repeatedEntry]
+	levels []zapcore.Level
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), dedup: c.dedup, levels: c.levels}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if containsLevel(c.levels, ent.Level) &&
+		!c.dedup.Check(ent.Level.String()+":"+ent.Message, repeatedEntry{core: c.Core, ent: ent, fields: fields}) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// Sync 先输出等待合并的重复日志汇总
+func (c *dedupCore) Sync() error {
+	c.dedup.Flush()
+	return c.Core.Sync()
+}
+
+func emitRepeated(e repeatedEntry, repeated int) {
+	fields := make([]zapcore.Field, 0, len(e.fields)+1)
+	fields = append(fields, e.fields...)
+	fields = append(fields, zap.Int(sample.RepeatedKey, repeated))
+	_ = e.core.Write(e.ent, fields)
+}
+
+func containsLevel(levels []zapcore.Level, level zapcore.Level) bool {
+	if len(levels) == 0 {
+		return true
+	}
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
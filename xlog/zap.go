@@ -1,56 +1,221 @@
-//go:build zap
-
 package xlog
 
 import (
+	"runtime"
+	"time"
+
+	"github.com/rabbit-rm/xgo/xlog/internal/sample"
 	"github.com/rabbit-rm/xgo/xlog/xzap"
 	"go.uber.org/zap"
+	zapbuffer "go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
 )
 
-type zapLogger struct {
-	l *zap.SugaredLogger
+func newZapCore(config Config, sinks []Sink) core {
+	// 级别由 AtomicLevel 统一控制，后端只按 Sink 的级别过滤
+	opts := []xzap.Option{
+		xzap.WithZapOptions(zap.WithFatalHook(noopHook{})),
+	}
+	if len(sinks) == 1 && sinks[0].isPlain() && !sinks[0].Format.shared() {
+		opts = append(opts,
+			xzap.WithLevel(zapcore.Level(sinks[0].Level)),
+			xzap.WithOutput(writerOnly{sinks[0].Writer}),
+		)
+		if sinks[0].Format == FormatJSON {
+			opts = append(opts, xzap.WithJSONEncoder())
+		}
+	} else {
+		zapSinks := make([]xzap.Sink, 0, len(sinks))
+		for _, sink := range sinks {
+			zapSinks = append(zapSinks, xzap.Sink{
+				Out:        writerOnly{sink.Writer},
+				WriteLevel: zapWriteLevel(sink.levelWriter()),
+				Level:      zapcore.Level(sink.Level),
+				Encoder:    zapEncoder(sink),
+				Filter:     zapFilter(sink.Filter),
+				Sampling:   zapSampling(sink.Sampling),
+				Dedup:      zapDedup(sink.Dedup),
+			})
+		}
+		opts = append(opts, xzap.WithSinks(zapSinks...))
+	}
+	// 调用方由 logger 解析后通过 Log 传入，无需 xzap 再次查找
+	opts = append(opts, xzap.DisableCaller())
+	if len(config.Hooks) > 0 {
+		opts = append(opts, xzap.WithZapOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			return ZapCore(c, config.Hooks...)
+		})))
+	}
+	return &zapCore{l: xzap.NewLogger(opts...).Desugar()}
+}
+
+type zapCore struct {
+	l *zap.Logger
+}
+
+func (c *zapCore) Log(t time.Time, level Level, template, msg string, fields []Field, frame *runtime.Frame) {
+	if ce := c.l.Check(zapcore.Level(level), msg); ce != nil {
+		ce.Time = t
+		if frame != nil {
+			ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+			ce.Caller.Function = frame.Function
+		}
+		zapFields := zapFields(fields)
+		if template != "" {
+			zapFields = append(zapFields, zap.Field{Key: sample.TemplateKey, Type: zapcore.SkipType, String: template})
+		}
+		if level == PanicLevel {
+			// CheckedEntry 在 PanicLevel 下写入后会 panic，panic 由 logger 处理
+			defer func() { _ = recover() }()
+		}
+		ce.Write(zapFields...)
+	}
+}
+
+// Sync 输出 zap core 中缓存的日志，Writer 由 logger 统一 Sync，这里不再重复
+func (c *zapCore) Sync() error {
+	return c.l.Sync()
 }
 
-func init() {
-	MustSetLogger(&zapLogger{l: xzap.NewLogger()})
+func (c *zapCore) With(fields []Field) core {
+	return &zapCore{l: c.l.With(zapFields(fields)...)}
 }
 
-func (logger *zapLogger) Debug(args ...interface{}) {
-	logger.l.Debug(args...)
+func zapEncoder(sink Sink) func(zapcore.EncoderConfig) zapcore.Encoder {
+	switch sink.Format {
+	case FormatJSON:
+		return zapcore.NewJSONEncoder
+	case FormatConsole, FormatLogfmt, FormatECS:
+		enc := newEncoder(sink)
+		return func(zapcore.EncoderConfig) zapcore.Encoder {
+			return &zapNativeEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder(), enc: enc}
+		}
+	default:
+		return zapcore.NewConsoleEncoder
+	}
 }
 
-func (logger *zapLogger) Info(args ...interface{}) {
-	logger.l.Info(args...)
+// zapBufferPool zapNativeEncoder 返回的 buffer 由 zap 负责释放
+var zapBufferPool = zapbuffer.NewPool()
+
+// zapNativeEncoder 以 xlog 编码器实现的格式输出 zap 日志，With 字段保存在内嵌的 MapObjectEncoder 中
+type zapNativeEncoder struct {
+	*zapcore.MapObjectEncoder
+	enc encoder
 }
 
-func (logger *zapLogger) Warn(args ...interface{}) {
-	logger.l.Warn(args...)
+func (e *zapNativeEncoder) Clone() zapcore.Encoder {
+	clone := zapcore.NewMapObjectEncoder()
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+	return &zapNativeEncoder{MapObjectEncoder: clone, enc: e.enc}
 }
 
-func (logger *zapLogger) Error(args ...interface{}) {
-	logger.l.Error(args...)
+func (e *zapNativeEncoder) EncodeEntry(ent zapcore.Entry, zapFields []zapcore.Field) (*zapbuffer.Buffer, error) {
+	with := make([]Field, 0, len(e.Fields)+len(zapFields))
+	for k, v := range e.Fields {
+		with = append(with, Field{Key: k, Value: v})
+	}
+	sortFields(with)
+	// With 字段排在前面，编码器不需要区分两者
+	fields := with
+	for _, f := range zapFields {
+		fields = appendZapField(fields, f)
+	}
+	entry := nativeEntry{time: ent.Time, level: Level(ent.Level), msg: ent.Message, fields: fields}
+	if ent.Caller.Defined {
+		entry.frame = entryFrame(ent.Caller.File, ent.Caller.Line, ent.Caller.Function)
+	}
+
+	buf := nativeBufferPool.Get()
+	defer buf.Free()
+	e.enc.encode(buf, entry)
+	out := zapBufferPool.Get()
+	_, _ = out.Write(buf.Bytes())
+	return out, nil
 }
 
-func (logger *zapLogger) Fatal(args ...interface{}) {
-	logger.l.Fatal(args...)
+// appendZapField 将 zap 字段还原为 xlog 字段，其余类型借助 MapObjectEncoder 转换
+func appendZapField(fields []Field, f zapcore.Field) []Field {
+	switch f.Type {
+	case zapcore.SkipType:
+		return fields
+	case zapcore.ErrorType, zapcore.StringerType, zapcore.ReflectType:
+		return append(fields, Field{Key: f.Key, Value: f.Interface})
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	start := len(fields)
+	for k, v := range enc.Fields {
+		fields = append(fields, Field{Key: k, Value: v})
+	}
+	sortFields(fields[start:])
+	return fields
 }
 
-func (logger *zapLogger) Debugf(format string, args ...interface{}) {
-	logger.l.Debugf(format, args...)
+func zapFilter(filter func(Entry) bool) func(zapcore.Entry, []zapcore.Field) bool {
+	if filter == nil {
+		return nil
+	}
+	return func(ent zapcore.Entry, zapFields []zapcore.Field) bool {
+		enc := zapcore.NewMapObjectEncoder()
+		for _, f := range zapFields {
+			f.AddTo(enc)
+		}
+		fields := make([]Field, 0, len(enc.Fields))
+		for k, v := range enc.Fields {
+			fields = append(fields, Field{Key: k, Value: v})
+		}
+		sortFields(fields)
+		return filter(Entry{Time: ent.Time, Level: Level(ent.Level), Message: ent.Message, Fields: fields})
+	}
 }
 
-func (logger *zapLogger) Infof(format string, args ...interface{}) {
-	logger.l.Infof(format, args...)
+func zapWriteLevel(write func(Level, []byte) (int, error)) func(zapcore.Level, []byte) (int, error) {
+	if write == nil {
+		return nil
+	}
+	return func(level zapcore.Level, p []byte) (int, error) {
+		return write(Level(level), p)
+	}
 }
 
-func (logger *zapLogger) Warnf(format string, args ...interface{}) {
-	logger.l.Warnf(format, args...)
+func zapSampling(sampling *Sampling) *xzap.Sampling {
+	if sampling == nil {
+		return nil
+	}
+	return &xzap.Sampling{
+		Tick:       sampling.Tick,
+		First:      sampling.First,
+		Thereafter: sampling.Thereafter,
+		Levels:     convertLevels(sampling.Levels, toZapLevel),
+	}
 }
 
-func (logger *zapLogger) Errorf(format string, args ...interface{}) {
-	logger.l.Errorf(format, args...)
+func zapDedup(dedup *Dedup) *xzap.Dedup {
+	if dedup == nil {
+		return nil
+	}
+	return &xzap.Dedup{
+		Window: dedup.Window,
+		Levels: convertLevels(dedup.Levels, toZapLevel),
+	}
 }
 
-func (logger *zapLogger) Fatalf(format string, args ...interface{}) {
-	logger.l.Fatalf(format, args...)
+func toZapLevel(level Level) zapcore.Level {
+	return zapcore.Level(level)
 }
+
+func zapFields(fields []Field) []zap.Field {
+	zapFields := make([]zap.Field, 0, len(fields))
+	for _, f := range fields {
+		zapFields = append(zapFields, zap.Any(f.Key, f.Value))
+	}
+	return zapFields
+}
+
+// noopHook 替换 zap 默认的 Fatal 退出行为，退出由 logger 处理
+type noopHook struct{}
+
+func (noopHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/IBM/sarama"
 	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/rabbit-rm/xgo/xlog"
 )
 
 // Consumer Kafka消费者结构体
@@ -22,6 +23,7 @@ type Consumer struct {
 	cancel      context.CancelFunc
 	config      *sarama.Config
 	retryConfig RetryConfig
+	log         xlog.Logger
 }
 
 // Handler 定义消息处理器接口
@@ -141,6 +143,7 @@ func NewConsumer(brokers []string, groupID string, topics []string, handler Hand
 		cancel:      cancel,
 		config:      config,
 		retryConfig: defaultRetryConfig,
+		log:         xlog.Named("xkafka").Named("consumer").With(xlog.Any("group", groupID)),
 	}
 
 	// 应用选项
@@ -155,9 +158,19 @@ func NewConsumer(brokers []string, groupID string, topics []string, handler Hand
 	}
 	consumer.client = client
 
+	// Consumer.Return.Errors 开启时必须读取错误，否则会阻塞消费
+	go consumer.handleErrors()
+
 	return consumer, nil
 }
 
+// handleErrors 记录消费过程中的错误，client 关闭后退出
+func (c *Consumer) handleErrors() {
+	for err := range c.client.Errors() {
+		c.log.Log(xlog.ErrorLevel, "consumer error", xlog.Err(err))
+	}
+}
+
 // retryWithBackoff 实现指数退避重试
 func (c *Consumer) retryWithBackoff(operation func() error) error {
 	var err error
@@ -174,6 +187,12 @@ func (c *Consumer) retryWithBackoff(operation func() error) error {
 			return gerror.Wrap(err, "max retries reached")
 		}
 
+		c.log.Log(xlog.WarnLevel, "operation failed, retrying",
+			xlog.Any("retries", retries+1),
+			xlog.Any("backoff", currentBackoff.String()),
+			xlog.Err(err),
+		)
+
 		// 检查是否需要退出
 		select {
 		case <-c.ctx.Done():
@@ -218,7 +237,7 @@ func (c *Consumer) Start() error {
 
 				if err != nil {
 					// 记录最终失败的错误
-					_ = gerror.Wrap(err, "consumer retry failed")
+					c.log.Log(xlog.ErrorLevel, "consumer retry failed", xlog.Err(err))
 				}
 
 				// 重置ready通道
@@ -230,6 +249,7 @@ func (c *Consumer) Start() error {
 	}()
 
 	<-c.ready
+	c.log.Log(xlog.InfoLevel, "consumer started", xlog.Any("topics", c.topics))
 	return nil
 }
 
@@ -241,6 +261,7 @@ func (c *Consumer) Stop() error {
 		if c.client != nil {
 			err = c.client.Close()
 		}
+		c.log.Info("consumer stopped")
 	})
 	return err
 }
@@ -0,0 +1,491 @@
+package xkafka
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rabbit-rm/xgo/xlog"
+	"github.com/rabbit-rm/xgo/xlog/xrotate"
+)
+
+// LogSink 将 xlog 输出的 JSON 日志按批发送到 Kafka，消息的 key 为服务名：
+//
+//	sink := xkafka.NewLogSink(producer, "app-logs",
+//		xkafka.WithLogService("order"),
+//		xkafka.WithLogFallback("/var/log/order/kafka.%Y%m%d%H.log"),
+//	)
+//	err := xlog.Init(xlog.Config{Sinks: []xlog.Sink{sink.Sink(xlog.InfoLevel)}})
+//
+// 日志先进入有界缓冲区，缓冲区满时丢弃；Kafka 不可用时写入本地的回退文件，
+// Kafka 恢复后按顺序重新发送回退文件并删除
+//
+// 投递语义为至少一次：一批中部分消息发送失败时整批写入回退文件，重新发送期间失败的日志也会再次发送，
+// 因此同一条日志可能重复；每批都等待 Producer 返回确定的结果，不会因超时放弃仍在进行的发送
+type LogSink struct {
+	publish func(values [][]byte) error
+	options logSinkOptions
+
+	entries   chan []byte
+	flushes   chan chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+	dropped   atomic.Uint64
+
+	// 以下字段只在 run 中访问
+	fallback io.WriteCloser
+	// pending 表示可能存在未重新发送的回退文件
+	pending  bool
+	failedAt time.Time
+}
+
+type logSinkOptions struct {
+	service          string
+	batchSize        int
+	bufferSize       int
+	flushInterval    time.Duration
+	retryInterval    time.Duration
+	fallbackPattern  string
+	fallbackRotation []xrotate.Option
+}
+
+// LogSinkOption 定义日志 Sink 配置选项接口
+type LogSinkOption interface {
+	apply(*logSinkOptions)
+}
+
+// funcLogSinkOption 将函数转换为选项接口
+type funcLogSinkOption struct {
+	f func(*logSinkOptions)
+}
+
+func (flo *funcLogSinkOption) apply(o *logSinkOptions) {
+	flo.f(o)
+}
+
+func newFuncLogSinkOption(f func(*logSinkOptions)) *funcLogSinkOption {
+	return &funcLogSinkOption{f: f}
+}
+
+// WithLogService 设置消息的 key，默认为可执行文件名
+func WithLogService(service string) LogSinkOption {
+	return newFuncLogSinkOption(func(o *logSinkOptions) {
+		o.service = service
+	})
+}
+
+// WithLogBatchSize 设置每批发送的日志条数，默认 500
+func WithLogBatchSize(size int) LogSinkOption {
+	return newFuncLogSinkOption(func(o *logSinkOptions) {
+		o.batchSize = size
+	})
+}
+
+// WithLogBufferSize 设置缓冲区可容纳的日志条数，默认 10000
+func WithLogBufferSize(size int) LogSinkOption {
+	return newFuncLogSinkOption(func(o *logSinkOptions) {
+		o.bufferSize = size
+	})
+}
+
+// WithLogFlushInterval 设置未满一批时的发送间隔，默认 1 秒
+func WithLogFlushInterval(interval time.Duration) LogSinkOption {
+	return newFuncLogSinkOption(func(o *logSinkOptions) {
+		o.flushInterval = interval
+	})
+}
+
+// WithLogRetryInterval 设置写入回退文件期间重新尝试 Kafka 的间隔，默认 30 秒
+func WithLogRetryInterval(interval time.Duration) LogSinkOption {
+	return newFuncLogSinkOption(func(o *logSinkOptions) {
+		o.retryInterval = interval
+	})
+}
+
+// WithLogSendTimeout 不再生效
+//
+// Deprecated: 超时返回后发送仍在进行，成功时日志既在 Kafka 中也在回退文件中，重新发送时重复；
+// 每批发送的耗时由 Producer 的 WithTimeout 与 WithRetry 控制
+func WithLogSendTimeout(time.Duration) LogSinkOption {
+	return newFuncLogSinkOption(func(*logSinkOptions) {})
+}
+
+// WithLogFallback 设置 Kafka 不可用时的回退文件，pattern 为 xrotate.NewRotateLogs 的文件名格式，
+// 未设置时发送失败的日志被丢弃
+func WithLogFallback(pattern string, opts ...xrotate.Option) LogSinkOption {
+	return newFuncLogSinkOption(func(o *logSinkOptions) {
+		o.fallbackPattern = pattern
+		o.fallbackRotation = opts
+	})
+}
+
+// NewLogSink 创建发送到 topic 的日志 Sink，producer 由调用方负责关闭
+func NewLogSink(producer *Producer, topic string, opts ...LogSinkOption) *LogSink {
+	options := logSinkOptions{}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	key := []byte(options.service)
+	if len(key) == 0 {
+		key = []byte(filepath.Base(os.Args[0]))
+	}
+	return newLogSink(func(values [][]byte) error {
+		return producer.sendMessages(topic, key, values)
+	}, options)
+}
+
+func newLogSink(publish func([][]byte) error, options logSinkOptions) *LogSink {
+	if options.batchSize <= 0 {
+		options.batchSize = 500
+	}
+	if options.bufferSize <= 0 {
+		options.bufferSize = 10000
+	}
+	if options.flushInterval <= 0 {
+		options.flushInterval = time.Second
+	}
+	if options.retryInterval <= 0 {
+		options.retryInterval = 30 * time.Second
+	}
+	s := &LogSink{
+		publish: publish,
+		options: options,
+		entries: make(chan []byte, options.bufferSize),
+		flushes: make(chan chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		// 上次运行遗留的回退文件在 Kafka 可用后重新发送
+		pending: options.fallbackPattern != "",
+	}
+	go s.run()
+	return s
+}
+
+// Sink 返回以 JSON 格式写入 LogSink 的 xlog.Sink
+func (s *LogSink) Sink(level xlog.Level) xlog.Sink {
+	return xlog.Sink{Writer: s, Level: level, Format: xlog.FormatJSON}
+}
+
+// Write 将一条日志放入缓冲区，缓冲区已满或 LogSink 已关闭时丢弃
+func (s *LogSink) Write(p []byte) (int, error) {
+	entry := bytes.Clone(bytes.TrimRight(p, "\n"))
+	select {
+	case <-s.done:
+		s.dropped.Add(1)
+		return len(p), nil
+	default:
+	}
+	select {
+	case s.entries <- entry:
+	default:
+		s.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of entries dropped because the buffer was full
+// or they could be neither published nor written to the fallback file.
+func (s *LogSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Sync 发送缓冲区中的所有日志
+func (s *LogSink) Sync() error {
+	ack := make(chan struct{})
+	select {
+	case s.flushes <- ack:
+		<-ack
+	case <-s.stopped:
+	}
+	return nil
+}
+
+// Close 发送缓冲区中的日志后停止，不会关闭 Producer
+func (s *LogSink) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	<-s.stopped
+	return nil
+}
+
+func (s *LogSink) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.options.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, s.options.batchSize)
+	for {
+		select {
+		case entry := <-s.entries:
+			batch = append(batch, entry)
+			if len(batch) >= s.options.batchSize {
+				batch = s.flush(batch)
+			}
+		case <-ticker.C:
+			if len(batch) == 0 && s.pending && s.retryDue() {
+				// 没有新日志时也尝试重新发送回退文件
+				if err := s.replay(); err != nil {
+					s.failedAt = time.Now()
+				}
+			}
+			batch = s.flush(batch)
+		case ack := <-s.flushes:
+			batch = s.flush(s.drain(batch))
+			close(ack)
+		case <-s.done:
+			s.flush(s.drain(batch))
+			s.closeFallback()
+			return
+		}
+	}
+}
+
+// drain 取出缓冲区中已有的日志
+func (s *LogSink) drain(batch [][]byte) [][]byte {
+	for {
+		select {
+		case entry := <-s.entries:
+			batch = append(batch, entry)
+		default:
+			return batch
+		}
+	}
+}
+
+func (s *LogSink) flush(batch [][]byte) [][]byte {
+	for start := 0; start < len(batch); start += s.options.batchSize {
+		s.send(batch[start:min(start+s.options.batchSize, len(batch))])
+	}
+	clear(batch)
+	return batch[:0]
+}
+
+// send 先重新发送回退文件再发送新日志，保证 Kafka 恢复后的顺序
+func (s *LogSink) send(values [][]byte) {
+	if s.pending {
+		if !s.retryDue() {
+			s.writeFallback(values)
+			return
+		}
+		if err := s.replay(); err != nil {
+			s.failedAt = time.Now()
+			s.writeFallback(values)
+			return
+		}
+	}
+	if err := s.publish(values); err != nil {
+		s.failedAt = time.Now()
+		s.writeFallback(values)
+	}
+}
+
+func (s *LogSink) retryDue() bool {
+	return s.failedAt.IsZero() || time.Since(s.failedAt) >= s.options.retryInterval
+}
+
+// writeFallback 写入回退文件，未设置回退文件或写入失败时丢弃
+func (s *LogSink) writeFallback(values [][]byte) {
+	if s.options.fallbackPattern == "" {
+		s.dropped.Add(uint64(len(values)))
+		return
+	}
+	if s.fallback == nil {
+		fallback, err := xrotate.NewRotateLogs(s.options.fallbackPattern, s.options.fallbackRotation...)
+		if err != nil {
+			s.dropped.Add(uint64(len(values)))
+			return
+		}
+		s.fallback = fallback
+	}
+	s.pending = true
+	for _, value := range values {
+		if _, err := s.fallback.Write(append(value, '\n')); err != nil {
+			s.dropped.Add(1)
+		}
+	}
+}
+
+func (s *LogSink) closeFallback() {
+	if s.fallback != nil {
+		_ = s.fallback.Close()
+		s.fallback = nil
+	}
+}
+
+// replay 关闭当前的回退文件，按修改时间依次重新发送，发送成功的文件被删除
+func (s *LogSink) replay() error {
+	s.closeFallback()
+	files, err := s.fallbackFiles()
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		if err := s.replayFile(name); err != nil {
+			return err
+		}
+	}
+	s.pending = false
+	return nil
+}
+
+// maxReplayLine 回退文件中单行的最大长度，超过的行被跳过并计入 Dropped
+const maxReplayLine = 16 * 1024 * 1024
+
+// replayFile 发送失败时只保留尚未发送的日志；超长的行被跳过，
+// 读取出错时剩余内容移到 .corrupt 文件中保留，不再重新发送，避免阻塞之后的回退文件
+func (s *LogSink) replayFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var (
+		// pos 为已读取的字节数，end 为 batch 中最后一行的结束位置，offset 之前的日志已发送
+		pos, end, offset int64
+		batch            [][]byte
+	)
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.publish(batch); err != nil {
+			return err
+		}
+		offset = end
+		batch = batch[:0]
+		return nil
+	}
+
+	skipping := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxReplayLine)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token := 0, []byte(nil)
+		switch i := bytes.IndexByte(data, '\n'); {
+		case i >= 0:
+			advance, token = i+1, data[:i]
+		case len(data) >= maxReplayLine:
+			// 超长行跳过到下一个换行
+			pos += int64(len(data))
+			skipping = true
+			return len(data), nil, nil
+		case atEOF && len(data) > 0:
+			advance, token = len(data), data
+		default:
+			return 0, nil, nil
+		}
+		pos += int64(advance)
+		if skipping {
+			skipping = false
+			s.dropped.Add(1)
+			return advance, nil, nil
+		}
+		return advance, token, nil
+	})
+	for scanner.Scan() {
+		end = pos
+		if line := scanner.Bytes(); len(line) > 0 {
+			batch = append(batch, bytes.Clone(line))
+		}
+		if len(batch) >= s.options.batchSize {
+			if err := send(); err != nil {
+				return s.truncate(f, name, offset, err)
+			}
+		}
+	}
+	if skipping {
+		s.dropped.Add(1)
+	}
+	end = pos
+	if err := send(); err != nil {
+		return s.truncate(f, name, offset, err)
+	}
+	if err := scanner.Err(); err != nil {
+		s.quarantine(f, name, offset)
+		return nil
+	}
+	_ = f.Close()
+	return os.Remove(name)
+}
+
+// truncate 用 offset 之后的内容替换回退文件，返回发送失败的原因
+func (s *LogSink) truncate(f *os.File, name string, offset int64, cause error) error {
+	if offset == 0 {
+		return cause
+	}
+	_ = copyFrom(f, offset, name)
+	return cause
+}
+
+// quarantine 将 offset 之后无法读取的内容移到 name.corrupt，复制失败时移动整个文件
+func (s *LogSink) quarantine(f *os.File, name string, offset int64) {
+	if err := copyFrom(f, offset, name+".corrupt"); err != nil {
+		_ = f.Close()
+		_ = os.Rename(name, name+".corrupt")
+		return
+	}
+	_ = f.Close()
+	_ = os.Remove(name)
+}
+
+// copyFrom 将 f 中 offset 之后的内容写入临时文件后重命名为 target
+func copyFrom(f *os.File, offset int64, target string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(target), filepath.Base(target)+".replay")
+	if err != nil {
+		return err
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err == nil {
+		_, err = io.Copy(tmp, f)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), target)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// fallbackFiles 返回按修改时间排序的回退文件，包括轮转产生的 .1、.2 等文件；
+// 只匹配由 pattern 生成的文件名，同目录下的其他文件不会被重新发送或删除
+func (s *LogSink) fallbackFiles() ([]string, error) {
+	matches, err := xrotate.Files(s.options.fallbackPattern)
+	if err != nil {
+		return nil, err
+	}
+	type file struct {
+		name    string
+		modTime time.Time
+	}
+	files := make([]file, 0, len(matches))
+	for _, name := range matches {
+		info, err := os.Lstat(name)
+		if err != nil {
+			continue
+		}
+		files = append(files, file{name: name, modTime: info.ModTime()})
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].modTime.Equal(files[j].modTime) {
+			return files[i].name < files[j].name
+		}
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.name)
+	}
+	return names, nil
+}
@@ -0,0 +1,130 @@
+package xkafka
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLogSinkFallback(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		available bool
+		published []string
+	)
+	publish := func(values [][]byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !available {
+			return errors.New("broker unavailable")
+		}
+		for _, v := range values {
+			published = append(published, string(v))
+		}
+		return nil
+	}
+
+	dir := t.TempDir()
+	// 同目录下的其他文件不会被重新发送或删除
+	foreign := filepath.Join(dir, "kafka.old.log")
+	if err := os.WriteFile(foreign, []byte(`{"msg":"foreign"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sink := newLogSink(publish, logSinkOptions{
+		batchSize:       2,
+		flushInterval:   time.Hour,
+		retryInterval:   time.Millisecond,
+		fallbackPattern: filepath.Join(dir, "kafka.%Y%m%d.log"),
+	})
+	defer sink.Close()
+
+	for i := 1; i <= 3; i++ {
+		_, _ = fmt.Fprintf(sink, `{"msg":"%d"}`+"\n", i)
+	}
+	_ = sink.Sync()
+	files, _ := filepath.Glob(filepath.Join(dir, "kafka.*[0-9].log"))
+	if len(files) != 1 {
+		t.Fatalf("expected a fallback file, got %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if strings.Count(string(data), "\n") != 3 {
+		t.Fatalf("expected 3 entries in fallback file, got %q", data)
+	}
+
+	mu.Lock()
+	available = true
+	mu.Unlock()
+	time.Sleep(2 * time.Millisecond)
+	_, _ = fmt.Fprintf(sink, `{"msg":"4"}`+"\n")
+	_ = sink.Sync()
+
+	mu.Lock()
+	got := strings.Join(published, ",")
+	mu.Unlock()
+	if want := `{"msg":"1"},{"msg":"2"},{"msg":"3"},{"msg":"4"}`; got != want {
+		t.Fatalf("published %s, want %s", got, want)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "kafka.*")); len(files) != 1 || files[0] != foreign {
+		t.Fatalf("fallback files should be removed after replay, got %v", files)
+	}
+	if sink.Dropped() != 0 {
+		t.Fatalf("unexpected dropped entries: %d", sink.Dropped())
+	}
+}
+
+func TestLogSinkReplayLongLine(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		published []string
+	)
+	publish := func(values [][]byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, v := range values {
+			published = append(published, string(v))
+		}
+		return nil
+	}
+
+	// 超长的行被跳过，不影响同一文件与之后文件中的其他日志
+	dir := t.TempDir()
+	long := strings.Repeat("x", maxReplayLine+1)
+	first := filepath.Join(dir, "kafka.20240101.log")
+	if err := os.WriteFile(first, []byte(`{"msg":"a"}`+"\n"+long+"\n"+`{"msg":"b"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second := filepath.Join(dir, "kafka.20240102.log")
+	if err := os.WriteFile(second, []byte(`{"msg":"c"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(first, past, past)
+
+	sink := newLogSink(publish, logSinkOptions{
+		batchSize:       10,
+		flushInterval:   time.Hour,
+		retryInterval:   time.Millisecond,
+		fallbackPattern: filepath.Join(dir, "kafka.%Y%m%d.log"),
+	})
+	defer sink.Close()
+	_, _ = fmt.Fprintf(sink, `{"msg":"d"}`+"\n")
+	_ = sink.Sync()
+
+	mu.Lock()
+	got := strings.Join(published, ",")
+	mu.Unlock()
+	if want := `{"msg":"a"},{"msg":"b"},{"msg":"c"},{"msg":"d"}`; got != want {
+		t.Fatalf("published %.200s, want %s", got, want)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "kafka.*")); len(files) != 0 {
+		t.Fatalf("fallback files should be removed after replay, got %v", files)
+	}
+	if sink.Dropped() != 1 {
+		t.Fatalf("dropped = %d, want 1", sink.Dropped())
+	}
+}
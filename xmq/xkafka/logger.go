@@ -0,0 +1,28 @@
+package xkafka
+
+import (
+	"github.com/IBM/sarama"
+	"github.com/rabbit-rm/xgo/xlog"
+)
+
+// useSaramaLog 将 sarama 的全局 Logger 与 DebugLogger 输出到名为 xkafka.sarama 的 xlog logger，
+// sarama 的日志是全局的，会影响进程内所有的 sarama 客户端
+func useSaramaLog(level, debugLevel xlog.Level) {
+	l := xlog.Named("xkafka").Named("sarama")
+	sarama.Logger = xlog.NewPrintLogger(l, level)
+	sarama.DebugLogger = xlog.NewPrintLogger(l, debugLevel)
+}
+
+// WithSaramaLog 将 sarama 的日志以 level 输出到 xlog，DebugLogger 的日志以 debugLevel 输出
+func WithSaramaLog(level, debugLevel xlog.Level) ProducerOption {
+	return newFuncProducerOption(func(*Producer) {
+		useSaramaLog(level, debugLevel)
+	})
+}
+
+// WithConsumerSaramaLog 与 WithSaramaLog 相同，用于消费者
+func WithConsumerSaramaLog(level, debugLevel xlog.Level) ConsumerOption {
+	return newFuncConsumerOption(func(*Consumer) {
+		useSaramaLog(level, debugLevel)
+	})
+}
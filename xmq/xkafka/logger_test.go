@@ -0,0 +1,34 @@
+package xkafka
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/rabbit-rm/xgo/xlog"
+)
+
+func TestSaramaLog(t *testing.T) {
+	var out bytes.Buffer
+	l, err := xlog.New(xlog.Config{Backend: xlog.BackendNative, Format: xlog.FormatJSON, Outputs: []io.Writer{&out}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev, prevLogger, prevDebug := xlog.L(), sarama.Logger, sarama.DebugLogger
+	xlog.MustSetLogger(l)
+	t.Cleanup(func() {
+		xlog.MustSetLogger(prev)
+		sarama.Logger, sarama.DebugLogger = prevLogger, prevDebug
+	})
+
+	WithSaramaLog(xlog.WarnLevel, xlog.DebugLevel).apply(&Producer{})
+	sarama.Logger.Printf("client/brokers registered new broker #%d\n", 1)
+	sarama.DebugLogger.Println("disabled at info level")
+
+	got := out.String()
+	if strings.Count(got, "\n") != 1 || !strings.Contains(got, `"level":"warn","msg":"client/brokers registered new broker #1","logger":"xkafka.sarama"`) {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/IBM/sarama"
 	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/rabbit-rm/xgo/xlog"
 )
 
 // Producer Kafka生产者结构体
@@ -17,6 +18,7 @@ type Producer struct {
 	brokers []string
 	mu      sync.RWMutex
 	closed  bool
+	log     xlog.Logger
 }
 
 // ProducerOption 定义生产者配置选项接口
@@ -75,6 +77,7 @@ func NewProducer(brokers []string, opts ...ProducerOption) (*Producer, error) {
 	producer := &Producer{
 		config:  config,
 		brokers: brokers,
+		log:     xlog.Named("xkafka").Named("producer"),
 	}
 
 	// 应用选项
@@ -100,10 +103,13 @@ func NewProducer(brokers []string, opts ...ProducerOption) (*Producer, error) {
 	// 启动异步生产者的错误处理
 	go producer.handleAsyncResults()
 
+	producer.log.Log(xlog.InfoLevel, "producer started", xlog.Any("brokers", brokers))
 	return producer, nil
 }
 
 // SendMessage 同步发送消息
+//
+// ctx 结束时立即返回，但已开始的发送不会取消，消息仍可能成功写入 Kafka
 func (p *Producer) SendMessage(ctx context.Context, topic string, key, value []byte) error {
 	p.mu.RLock()
 	if p.closed {
@@ -136,6 +142,46 @@ func (p *Producer) SendMessage(ctx context.Context, topic string, key, value []b
 	}
 }
 
+// SendMessages 同步批量发送消息，所有消息使用相同的 key
+//
+// ctx 结束时立即返回，但已开始的发送不会取消，消息仍可能成功写入 Kafka
+func (p *Producer) SendMessages(ctx context.Context, topic string, key []byte, values [][]byte) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- p.sendMessages(topic, key, values)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return gerror.Wrap(ctx.Err(), "context canceled")
+	case err := <-done:
+		return err
+	}
+}
+
+// sendMessages 同步批量发送消息，等待 Kafka 的结果，耗时由 Producer 的超时与重试配置决定
+func (p *Producer) sendMessages(topic string, key []byte, values [][]byte) error {
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return ErrProducerClosed
+	}
+	p.mu.RUnlock()
+
+	msgs := make([]*sarama.ProducerMessage, 0, len(values))
+	for _, value := range values {
+		msgs = append(msgs, &sarama.ProducerMessage{
+			Topic: topic,
+			Key:   sarama.ByteEncoder(key),
+			Value: sarama.ByteEncoder(value),
+		})
+	}
+	if err := p.sync.SendMessages(msgs); err != nil {
+		return gerror.Wrap(err, "send messages")
+	}
+	return nil
+}
+
 // SendMessageAsync 异步发送消息
 func (p *Producer) SendMessageAsync(topic string, key, value []byte) error {
 	p.mu.RLock()
@@ -163,12 +209,21 @@ func (p *Producer) handleAsyncResults() {
 			if !ok {
 				return
 			}
-			_ = success
+			if p.log.Enabled(xlog.DebugLevel) {
+				p.log.Log(xlog.DebugLevel, "async message sent",
+					xlog.Any("topic", success.Topic),
+					xlog.Any("partition", success.Partition),
+					xlog.Any("offset", success.Offset),
+				)
+			}
 		case err, ok := <-p.async.Errors():
 			if !ok {
 				return
 			}
-			_ = gerror.Wrap(err, "async send message")
+			p.log.Log(xlog.ErrorLevel, "async send message failed",
+				xlog.Any("topic", err.Msg.Topic),
+				xlog.Err(err.Err),
+			)
 		}
 	}
 }
@@ -191,5 +246,10 @@ func (p *Producer) Close() error {
 		err = gerror.Wrap(err, "close sync producer")
 	}
 
+	if err != nil {
+		p.log.Log(xlog.WarnLevel, "producer closed with error", xlog.Err(err))
+	} else {
+		p.log.Info("producer closed")
+	}
 	return err
 }
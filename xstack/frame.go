@@ -3,12 +3,18 @@ package xstack
 import (
 	"runtime"
 
+	"github.com/rabbit-rm/xgo/internal/caller"
 	"github.com/rabbit-rm/xgo/internal/stacktrace"
 )
 
+// Capture 返回 skip 指定的帧，通过 xlog.Helper 标记的辅助函数会被跳过
 func Capture(skip int) runtime.Frame {
-	stack := stacktrace.Capture(skip+1, stacktrace.First)
+	stack := stacktrace.Capture(skip+1, stacktrace.Full)
 	defer stack.Free()
-	frame, _ := stack.Next()
-	return frame
+	for {
+		frame, more := stack.Next()
+		if !more || !caller.IsHelper(frame.Function) {
+			return frame
+		}
+	}
 }